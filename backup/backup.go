@@ -0,0 +1,271 @@
+package backup
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/RedDocMD/dotted/config"
+	"github.com/RedDocMD/dotted/fs"
+	"github.com/RedDocMD/dotted/store"
+	"github.com/pkg/errors"
+)
+
+var Fs = fs.OsFs
+var Afs = fs.OsAfs
+
+// FileInfo describes a file held by a target. Path is relative
+// to the root of the store and always uses forward slashes.
+type FileInfo struct {
+	Path     string
+	Size     int64
+	Checksum string // Hex encoded SHA1 of the contents
+}
+
+// Target is a location the store can be backed up to and restored from.
+type Target interface {
+	Name() string
+	// List returns every file held by the target.
+	List() ([]FileInfo, error)
+	// Stat returns FileNotFound if path is not held by the target.
+	Stat(path string) (FileInfo, error)
+	Push(path string, data []byte) error
+	Pull(path string) ([]byte, error)
+	// Close releases the target once a transfer is over.
+	Close() error
+}
+
+var FileNotFound = errors.New("file not found on target")
+
+// New creates the target described by entry.
+func New(entry config.TargetEntry) (Target, error) {
+	switch entry.Type {
+	case config.DirTarget:
+		return NewDirTarget(entry.Name, entry.Path), nil
+	case config.GitTarget:
+		return NewGitTarget(entry.Name, entry.URL, entry.Branch, entry.Path)
+	case config.S3Target:
+		return NewS3Target(entry)
+	case config.SFTPTarget:
+		return NewSFTPTarget(entry)
+	}
+	return nil, fmt.Errorf("failed to create target %s: unknown type %q", entry.Name, entry.Type)
+}
+
+// Report summarises a transfer between the store and a target.
+type Report struct {
+	Transferred []string
+	Unchanged   int
+	Skipped     []string
+	// Encrypted files, which are kept off targets holding readable
+	// copies
+	Encrypted []string
+}
+
+// PushStore copies every file in the store at storePath which is
+// missing or different on target. Files are never deleted from the
+// target, so a file removed from the store stays there.
+func PushStore(storePath string, target Target) (Report, error) {
+	return pushStore(storePath, storePath, nil, target)
+}
+
+// pushStore pushes the store at storePath with the manifests of the
+// store at manifestPath, which list the files of both. The directories
+// of the store named in skip are not pushed.
+func pushStore(storePath, manifestPath string, skip []string, target Target) (Report, error) {
+	var report Report
+	local, err := listDir(storePath)
+	if err != nil {
+		return report, errors.Wrapf(err, "failed to push to %s", target.Name())
+	}
+	if manifestPath != storePath {
+		if local, err = withManifests(local, manifestPath); err != nil {
+			return report, errors.Wrapf(err, "failed to push to %s", target.Name())
+		}
+	}
+	remote, err := target.List()
+	if err != nil {
+		return report, errors.Wrapf(err, "failed to push to %s", target.Name())
+	}
+	remoteByPath := infoByPath(remote)
+	for _, info := range local {
+		if inWorkTree(info.Path) || inDirs(info.Path, skip) {
+			continue
+		}
+		if remoteInfo, ok := remoteByPath[info.Path]; ok && remoteInfo.Checksum == info.Checksum {
+			report.Unchanged += 1
+			continue
+		}
+		root := storePath
+		if isManifest(info.Path) {
+			root = manifestPath
+		}
+		data, err := Afs.ReadFile(Fs.Join(root, filepath.FromSlash(info.Path)))
+		if err != nil {
+			return report, errors.Wrapf(err, "failed to push to %s", target.Name())
+		}
+		if err = target.Push(info.Path, data); err != nil {
+			return report, errors.Wrapf(err, "failed to push to %s", target.Name())
+		}
+		report.Transferred = append(report.Transferred, info.Path)
+	}
+	return report, nil
+}
+
+// PullStore copies every file on target which is missing or different
+// in the store at storePath. Files only present in the store are kept.
+func PullStore(storePath string, target Target) (Report, error) {
+	var report Report
+	local, err := listDir(storePath)
+	if err != nil {
+		return report, errors.Wrapf(err, "failed to pull from %s", target.Name())
+	}
+	remote, err := target.List()
+	if err != nil {
+		return report, errors.Wrapf(err, "failed to pull from %s", target.Name())
+	}
+	localByPath := infoByPath(local)
+	for _, info := range remote {
+		if inWorkTree(info.Path) {
+			continue
+		}
+		if localInfo, ok := localByPath[info.Path]; ok && localInfo.Checksum == info.Checksum {
+			report.Unchanged += 1
+			continue
+		}
+		data, err := target.Pull(info.Path)
+		if err != nil {
+			return report, errors.Wrapf(err, "failed to pull from %s", target.Name())
+		}
+		if checksum(data) != info.Checksum {
+			return report, fmt.Errorf("failed to pull from %s: checksum mismatch for %s", target.Name(), info.Path)
+		}
+		if err = writeFile(Fs.Join(storePath, filepath.FromSlash(info.Path)), data); err != nil {
+			return report, errors.Wrapf(err, "failed to pull from %s", target.Name())
+		}
+		report.Transferred = append(report.Transferred, info.Path)
+	}
+	return report, nil
+}
+
+// inWorkTree reports whether path, relative to the store, is in its
+// work tree. The work tree only holds copies of the current versions,
+// which may have uncommitted edits and are in plain text even for
+// encrypted files, so it is never transferred.
+func inWorkTree(path string) bool {
+	return strings.HasPrefix(path, store.WorkTreeDir+"/")
+}
+
+func isManifest(path string) bool {
+	for _, manifest := range store.Manifests {
+		if path == manifest {
+			return true
+		}
+	}
+	return false
+}
+
+// withManifests replaces the manifests in infos with those of the
+// store at manifestPath.
+func withManifests(infos []FileInfo, manifestPath string) ([]FileInfo, error) {
+	var replaced []FileInfo
+	for _, info := range infos {
+		if !isManifest(info.Path) {
+			replaced = append(replaced, info)
+		}
+	}
+	for _, manifest := range store.Manifests {
+		data, err := Afs.ReadFile(Fs.Join(manifestPath, manifest))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		replaced = append(replaced, FileInfo{
+			Path:     manifest,
+			Size:     int64(len(data)),
+			Checksum: checksum(data),
+		})
+	}
+	sort.Slice(replaced, func(i, j int) bool {
+		return replaced[i].Path < replaced[j].Path
+	})
+	return replaced, nil
+}
+
+// inDirs reports whether path, relative to the store, is inside one
+// of dirs.
+func inDirs(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		if strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func infoByPath(infos []FileInfo) map[string]FileInfo {
+	byPath := make(map[string]FileInfo, len(infos))
+	for _, info := range infos {
+		byPath[info.Path] = info
+	}
+	return byPath
+}
+
+func checksum(data []byte) string {
+	sum := sha1.Sum(data)
+	return fmt.Sprintf("%x", sum)
+}
+
+// listDir describes every regular file under root, sorted by path.
+// A missing root is treated as empty.
+func listDir(root string) ([]FileInfo, error) {
+	var infos []FileInfo
+	exists, err := Afs.DirExists(root)
+	if err != nil || !exists {
+		return infos, err
+	}
+	err = Afs.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		data, err := Afs.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		infos = append(infos, FileInfo{
+			Path:     filepath.ToSlash(relPath),
+			Size:     info.Size(),
+			Checksum: checksum(data),
+		})
+		return nil
+	})
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Path < infos[j].Path
+	})
+	return infos, err
+}
+
+// writeFile replaces the file at path with data, creating parent
+// directories as needed. The data is first written to a temporary
+// file so that an interrupted write never leaves a truncated file.
+func writeFile(path string, data []byte) error {
+	if err := Afs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := Afs.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return Afs.Rename(tmpPath, path)
+}
@@ -0,0 +1,139 @@
+package backup
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/RedDocMD/dotted/config"
+	"github.com/RedDocMD/dotted/crypt"
+	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/fs"
+	"github.com/RedDocMD/dotted/store"
+	"github.com/stretchr/testify/suite"
+)
+
+type BackupSuite struct {
+	suite.Suite
+	target *DirTarget
+}
+
+func (suite *BackupSuite) SetupSuite() {
+	Fs, file.Fs, store.Fs = fs.MockFs, fs.MockFs, fs.MockFs
+	Afs, file.Afs, store.Afs = fs.MockAfs, fs.MockAfs, fs.MockAfs
+}
+
+func (suite *BackupSuite) TearDownSuite() {
+	Fs, file.Fs, store.Fs = fs.OsFs, fs.OsFs, fs.OsFs
+	Afs, file.Afs, store.Afs = fs.OsAfs, fs.OsAfs, fs.OsAfs
+}
+
+func (suite *BackupSuite) SetupTest() {
+	Afs.MkdirAll("/store/14b4f00abd93c6222516ff054e4a9f66295d03fa", 0755)
+	Afs.WriteFile("/store/paths", []byte(".config/alacritty/alacritty.yml\n"), 0644)
+	Afs.WriteFile("/store/14b4f00abd93c6222516ff054e4a9f66295d03fa/content", []byte("font:\n  size: 11\n"), 0644)
+	Afs.WriteFile("/store/14b4f00abd93c6222516ff054e4a9f66295d03fa/metadata", []byte("{}"), 0644)
+	suite.target = NewDirTarget("drive", "/mnt/drive/dotted")
+}
+
+func (suite *BackupSuite) TearDownTest() {
+	Afs.RemoveAll("/")
+}
+
+func TestBackupSuite(t *testing.T) {
+	suite.Run(t, &BackupSuite{})
+}
+
+func (suite *BackupSuite) TestPushIsIncremental() {
+	report, err := PushStore("/store", suite.target)
+	suite.Nil(err)
+	suite.Len(report.Transferred, 3)
+	suite.Equal(0, report.Unchanged)
+	data, err := Afs.ReadFile("/mnt/drive/dotted/14b4f00abd93c6222516ff054e4a9f66295d03fa/content")
+	suite.Nil(err)
+	suite.Equal("font:\n  size: 11\n", string(data))
+
+	Afs.WriteFile("/store/14b4f00abd93c6222516ff054e4a9f66295d03fa/content", []byte("font:\n  size: 12\n"), 0644)
+	report, err = PushStore("/store", suite.target)
+	suite.Nil(err)
+	suite.Equal([]string{"14b4f00abd93c6222516ff054e4a9f66295d03fa/content"}, report.Transferred)
+	suite.Equal(2, report.Unchanged)
+}
+
+func (suite *BackupSuite) TestPushSkipsWorkTree() {
+	Afs.MkdirAll("/store/tree/.config/alacritty", 0755)
+	Afs.WriteFile("/store/tree/.config/alacritty/alacritty.yml", []byte("font:\n  size: 14\n"), 0644)
+	report, err := PushStore("/store", suite.target)
+	suite.Nil(err)
+	suite.Len(report.Transferred, 3)
+	exists, _ := Afs.Exists("/mnt/drive/dotted/tree/.config/alacritty/alacritty.yml")
+	suite.False(exists)
+}
+
+func (suite *BackupSuite) TestPushKeepsEncryptedSymlinkSecret() {
+	cipher, err := crypt.NewPassphraseCipher("passphrase")
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	file.Encryption = cipher
+	defer func() { file.Encryption = nil }()
+	secret := "machine example.com password hunter2\n"
+	Afs.WriteFile("/home/dknite/.netrc", []byte(secret), 0600)
+	fileStore, err := store.LoadFromDisk(&config.Config{
+		Name: "Linux",
+		WithHistory: []config.FileEntry{
+			{Path: ".netrc", Encrypted: true, Deploy: config.SymlinkDeploy},
+		},
+		StoreLocation: "/laptop/store",
+	})
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.Nil(fileStore.SaveToDisk())
+	// Applying writes the content to the work tree in plain text
+	dotFile := fileStore.Files()[0]
+	suite.True(strings.HasPrefix(dotFile.WorkPath(), "/laptop/store/tree/"))
+	Afs.MkdirAll("/laptop/store/tree", 0755)
+	Afs.WriteFile(dotFile.WorkPath(), []byte(secret), 0600)
+
+	report, err := PushStore("/laptop/store", suite.target)
+	suite.Nil(err)
+	suite.NotEmpty(report.Transferred)
+	pushed, err := listDir("/mnt/drive/dotted")
+	suite.Nil(err)
+	suite.Len(pushed, len(report.Transferred))
+	for _, info := range pushed {
+		suite.False(strings.HasPrefix(info.Path, store.WorkTreeDir+"/"))
+		data, err := Afs.ReadFile("/mnt/drive/dotted/" + info.Path)
+		suite.Nil(err)
+		suite.NotContains(string(data), "hunter2", info.Path)
+	}
+}
+
+func (suite *BackupSuite) TestPullRestoresStore() {
+	_, err := PushStore("/store", suite.target)
+	suite.Nil(err)
+	Afs.RemoveAll("/store")
+
+	report, err := PullStore("/store", suite.target)
+	suite.Nil(err)
+	suite.Len(report.Transferred, 3)
+	data, err := Afs.ReadFile("/store/paths")
+	suite.Nil(err)
+	suite.Equal(".config/alacritty/alacritty.yml\n", string(data))
+
+	report, err = PullStore("/store", suite.target)
+	suite.Nil(err)
+	suite.Len(report.Transferred, 0)
+	suite.Equal(3, report.Unchanged)
+}
+
+func (suite *BackupSuite) TestStat() {
+	_, err := suite.target.Stat("paths")
+	suite.ErrorIs(err, FileNotFound)
+	_, err = PushStore("/store", suite.target)
+	suite.Nil(err)
+	info, err := suite.target.Stat("paths")
+	suite.Nil(err)
+	suite.Equal(int64(32), info.Size)
+	suite.Equal(checksum([]byte(".config/alacritty/alacritty.yml\n")), info.Checksum)
+}
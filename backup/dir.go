@@ -0,0 +1,71 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// DirTarget mirrors the store into a directory, such as one on a
+// mounted drive.
+type DirTarget struct {
+	name string
+	root string
+}
+
+func NewDirTarget(name, root string) *DirTarget {
+	return &DirTarget{name: name, root: root}
+}
+
+func (target *DirTarget) Name() string {
+	return target.name
+}
+
+func (target *DirTarget) List() ([]FileInfo, error) {
+	infos, err := listDir(target.root)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list %s", target.root)
+	}
+	return infos, nil
+}
+
+func (target *DirTarget) Stat(path string) (FileInfo, error) {
+	data, err := Afs.ReadFile(target.fullPath(path))
+	if os.IsNotExist(err) {
+		return FileInfo{}, FileNotFound
+	} else if err != nil {
+		return FileInfo{}, errors.Wrapf(err, "failed to stat %s", path)
+	}
+	info := FileInfo{
+		Path:     path,
+		Size:     int64(len(data)),
+		Checksum: checksum(data),
+	}
+	return info, nil
+}
+
+func (target *DirTarget) Push(path string, data []byte) error {
+	if err := writeFile(target.fullPath(path), data); err != nil {
+		return errors.Wrapf(err, "failed to push %s", path)
+	}
+	return nil
+}
+
+func (target *DirTarget) Pull(path string) ([]byte, error) {
+	data, err := Afs.ReadFile(target.fullPath(path))
+	if os.IsNotExist(err) {
+		return nil, FileNotFound
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to pull %s", path)
+	}
+	return data, nil
+}
+
+func (target *DirTarget) Close() error {
+	return nil
+}
+
+func (target *DirTarget) fullPath(path string) string {
+	return Fs.Join(target.root, filepath.FromSlash(path))
+}
@@ -0,0 +1,105 @@
+package backup
+
+import (
+	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/store"
+	"github.com/pkg/errors"
+)
+
+// FileTarget is a Target which holds a readable copy of the current
+// content of every dot-file, keyed by its path relative to $HOME,
+// instead of a mirror of the store.
+type FileTarget interface {
+	Target
+	HoldsFiles()
+}
+
+// Push backs up fileStore to target. The store must have been saved
+// to disk beforehand.
+func Push(fileStore *store.Store, target Target) (Report, error) {
+	if _, ok := target.(FileTarget); ok {
+		return PushFiles(fileStore.Files(), target)
+	}
+	return PushStore(fileStore.Path(), target)
+}
+
+// Pull restores fileStore from target. Targets which mirror the store
+// overwrite it on disk, so fileStore must be reloaded afterwards.
+func Pull(fileStore *store.Store, target Target) (Report, error) {
+	if _, ok := target.(FileTarget); ok {
+		return PullFiles(fileStore.Files(), target)
+	}
+	return PullStore(fileStore.Path(), target)
+}
+
+// PushFiles copies the current content of every dot-file which is
+// missing or different on target. Encrypted files are never copied,
+// as the target would hold them in plain text.
+func PushFiles(dotFiles []*file.DotFile, target Target) (Report, error) {
+	var report Report
+	for _, dotFile := range dotFiles {
+		path := dotFile.RelativePath()
+		if dotFile.IsEncrypted() {
+			report.Encrypted = append(report.Encrypted, path)
+			continue
+		}
+		content := []byte(dotFile.Content())
+		info, err := target.Stat(path)
+		if err == nil && info.Checksum == checksum(content) {
+			report.Unchanged += 1
+			continue
+		} else if err != nil && !errors.Is(err, FileNotFound) {
+			return report, errors.WithMessagef(err, "failed to push to %s", target.Name())
+		}
+		if err = target.Push(path, content); err != nil {
+			return report, errors.WithMessagef(err, "failed to push to %s", target.Name())
+		}
+		report.Transferred = append(report.Transferred, path)
+	}
+	return report, nil
+}
+
+// PullFiles records every version of a dot-file on target which is
+// new as a child of its current history node. The current node is
+// left as it is, so nothing already in the store is overwritten.
+// Changes to files without history cannot be recorded, so they are
+// reported as skipped. Encrypted files are not pulled, as they are
+// never pushed.
+func PullFiles(dotFiles []*file.DotFile, target Target) (Report, error) {
+	var report Report
+	for _, dotFile := range dotFiles {
+		path := dotFile.RelativePath()
+		if dotFile.IsEncrypted() {
+			report.Encrypted = append(report.Encrypted, path)
+			continue
+		}
+		info, err := target.Stat(path)
+		if errors.Is(err, FileNotFound) {
+			continue
+		} else if err != nil {
+			return report, errors.WithMessagef(err, "failed to pull from %s", target.Name())
+		}
+		if info.Checksum == checksum([]byte(dotFile.Content())) {
+			report.Unchanged += 1
+			continue
+		}
+		if !dotFile.HasHistory() {
+			report.Skipped = append(report.Skipped, path)
+			continue
+		}
+		data, err := target.Pull(path)
+		if err != nil {
+			return report, errors.WithMessagef(err, "failed to pull from %s", target.Name())
+		}
+		node, err := dotFile.AddVersion(string(data))
+		if err != nil {
+			return report, errors.WithMessagef(err, "failed to pull from %s", target.Name())
+		}
+		if node == nil {
+			report.Unchanged += 1
+		} else {
+			report.Transferred = append(report.Transferred, path)
+		}
+	}
+	return report, nil
+}
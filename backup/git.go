@@ -0,0 +1,167 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/RedDocMD/dotted/git"
+	"github.com/pkg/errors"
+)
+
+const defaultBranch = "main"
+
+// GitTarget keeps the current version of every dot-file in a git
+// repository and pushes it to a remote. The repository is laid out
+// like $HOME, so it can be browsed with any git tooling.
+type GitTarget struct {
+	name   string
+	url    string
+	branch string
+	repo   *git.Repo
+}
+
+// NewGitTarget prepares a working clone of the repository at url in
+// workDir, up to date with the remote branch. An empty workDir uses a
+// directory in the user's cache.
+func NewGitTarget(name, url, branch, workDir string) (*GitTarget, error) {
+	if len(branch) == 0 {
+		branch = defaultBranch
+	}
+	if len(workDir) == 0 {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to open target %s", name)
+		}
+		workDir = filepath.Join(cacheDir, "dotted", "git", name)
+	}
+	var repo *git.Repo
+	if git.IsRepo(workDir) {
+		repo = git.Open(workDir)
+		if _, err := repo.Run("remote", "set-url", "origin", url); err != nil {
+			return nil, errors.WithMessagef(err, "failed to open target %s", name)
+		}
+	} else {
+		var err error
+		repo, err = git.Init(workDir, false)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to open target %s", name)
+		}
+		if _, err = repo.Run("remote", "add", "origin", url); err != nil {
+			return nil, errors.WithMessagef(err, "failed to open target %s", name)
+		}
+	}
+	target := &GitTarget{
+		name:   name,
+		url:    url,
+		branch: branch,
+		repo:   repo,
+	}
+	if err := target.sync(); err != nil {
+		return nil, errors.WithMessagef(err, "failed to open target %s", name)
+	}
+	return target, nil
+}
+
+// sync fetches the remote and resets the working tree to its branch,
+// or to an empty branch if the remote does not have one yet.
+func (target *GitTarget) sync() error {
+	if _, err := target.repo.Run("fetch", "--quiet", "origin"); err != nil {
+		return err
+	}
+	remoteBranch := "refs/remotes/origin/" + target.branch
+	if _, ok := target.repo.RevParse(remoteBranch); ok {
+		if _, err := target.repo.Run("checkout", "--quiet", "--force", "-B", target.branch, remoteBranch); err != nil {
+			return err
+		}
+	} else if _, err := target.repo.Run("symbolic-ref", "HEAD", "refs/heads/"+target.branch); err != nil {
+		return err
+	}
+	_, err := target.repo.Run("clean", "--quiet", "--force", "-d")
+	return err
+}
+
+func (target *GitTarget) Name() string {
+	return target.name
+}
+
+func (target *GitTarget) HoldsFiles() {}
+
+func (target *GitTarget) List() ([]FileInfo, error) {
+	out, err := target.repo.Run("ls-files", "-z")
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to list %s", target.name)
+	}
+	var infos []FileInfo
+	for _, path := range strings.Split(out, "\x00") {
+		if len(path) == 0 {
+			continue
+		}
+		info, err := target.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (target *GitTarget) Stat(path string) (FileInfo, error) {
+	data, err := os.ReadFile(target.fullPath(path))
+	if os.IsNotExist(err) {
+		return FileInfo{}, FileNotFound
+	} else if err != nil {
+		return FileInfo{}, errors.Wrapf(err, "failed to stat %s", path)
+	}
+	info := FileInfo{
+		Path:     path,
+		Size:     int64(len(data)),
+		Checksum: checksum(data),
+	}
+	return info, nil
+}
+
+func (target *GitTarget) Push(path string, data []byte) error {
+	fullPath := target.fullPath(path)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return errors.Wrapf(err, "failed to push %s", path)
+	}
+	if err := os.WriteFile(fullPath, data, 0644); err != nil {
+		return errors.Wrapf(err, "failed to push %s", path)
+	}
+	return nil
+}
+
+func (target *GitTarget) Pull(path string) ([]byte, error) {
+	data, err := os.ReadFile(target.fullPath(path))
+	if os.IsNotExist(err) {
+		return nil, FileNotFound
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to pull %s", path)
+	}
+	return data, nil
+}
+
+// Close commits whatever was pushed and pushes it to the remote.
+func (target *GitTarget) Close() error {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown host"
+	}
+	committed, err := target.repo.Commit(fmt.Sprintf("Update dot-files from %s", hostname))
+	if err != nil {
+		return errors.WithMessagef(err, "failed to commit to %s", target.name)
+	}
+	if !committed {
+		return nil
+	}
+	if _, err = target.repo.Run("push", "--quiet", "origin", target.branch); err != nil {
+		return errors.WithMessagef(err, "failed to push to %s", target.name)
+	}
+	return nil
+}
+
+func (target *GitTarget) fullPath(path string) string {
+	return filepath.Join(target.repo.Dir, filepath.FromSlash(path))
+}
@@ -0,0 +1,144 @@
+package backup
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/fs"
+	"github.com/RedDocMD/dotted/git"
+	"github.com/stretchr/testify/suite"
+)
+
+type GitSuite struct {
+	suite.Suite
+	remote  string
+	workDir string
+	tmux    *file.DotFile
+	fish    *file.DotFile
+}
+
+func (suite *GitSuite) SetupSuite() {
+	if _, err := exec.LookPath("git"); err != nil {
+		suite.T().Skip("git not installed")
+	}
+	file.Fs = fs.MockFs
+	file.Afs = fs.MockAfs
+}
+
+func (suite *GitSuite) TearDownSuite() {
+	file.Fs = fs.OsFs
+	file.Afs = fs.OsAfs
+}
+
+func (suite *GitSuite) SetupTest() {
+	tmpDir := suite.T().TempDir()
+	suite.remote = filepath.Join(tmpDir, "remote.git")
+	suite.workDir = filepath.Join(tmpDir, "work")
+	if _, err := git.Init(suite.remote, true); err != nil {
+		suite.T().Fatal(err)
+	}
+	file.Afs.MkdirAll("/home/dknite/.config/fish", 0755)
+	file.Afs.WriteFile("/home/dknite/.tmux.conf", []byte("set -g mouse on\n"), 0644)
+	file.Afs.WriteFile("/home/dknite/.config/fish/config.fish", []byte("set -x EDITOR nvim\n"), 0644)
+	suite.tmux, _ = file.NewDotFile("/home/dknite/.tmux.conf", "tmux", true)
+	suite.fish, _ = file.NewDotFile("/home/dknite/.config/fish/config.fish", "fish", false)
+}
+
+func (suite *GitSuite) TearDownTest() {
+	file.Afs.RemoveAll("/")
+}
+
+func TestGitSuite(t *testing.T) {
+	suite.Run(t, &GitSuite{})
+}
+
+func (suite *GitSuite) pushAll() Report {
+	target, err := NewGitTarget("git", suite.remote, "", suite.workDir)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	report, err := PushFiles([]*file.DotFile{suite.tmux, suite.fish}, target)
+	suite.Nil(err)
+	suite.Nil(target.Close())
+	return report
+}
+
+func (suite *GitSuite) TestPushToBareRepo() {
+	report := suite.pushAll()
+	suite.Equal([]string{".tmux.conf", ".config/fish/config.fish"}, report.Transferred)
+
+	remote := git.Open(suite.remote)
+	out, err := remote.Run("show", "main:.tmux.conf")
+	suite.Nil(err)
+	suite.Equal("set -g mouse on\n", out)
+
+	report = suite.pushAll()
+	suite.Len(report.Transferred, 0)
+	suite.Equal(2, report.Unchanged)
+	out, err = remote.Run("rev-list", "--count", "main")
+	suite.Nil(err)
+	suite.Equal("1\n", out)
+}
+
+func (suite *GitSuite) TestPullAddsHistoryNodes() {
+	suite.pushAll()
+
+	// Another machine commits to the remote
+	otherDir := filepath.Join(suite.T().TempDir(), "other")
+	other, err := NewGitTarget("git", suite.remote, "", otherDir)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.Nil(other.Push(".tmux.conf", []byte("set -g mouse off\n")))
+	suite.Nil(other.Push(".config/fish/config.fish", []byte("set -x EDITOR vim\n")))
+	suite.Nil(other.Close())
+
+	target, err := NewGitTarget("git", suite.remote, "", suite.workDir)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	report, err := PullFiles([]*file.DotFile{suite.tmux, suite.fish}, target)
+	suite.Nil(err)
+	suite.Nil(target.Close())
+	suite.Equal([]string{".tmux.conf"}, report.Transferred)
+	suite.Equal([]string{".config/fish/config.fish"}, report.Skipped)
+	suite.Equal("set -g mouse on\n", suite.tmux.Content())
+	suite.Equal("set -x EDITOR nvim\n", suite.fish.Content())
+
+	// Pulling again does not add the same version twice
+	target, err = NewGitTarget("git", suite.remote, "", suite.workDir)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	report, err = PullFiles([]*file.DotFile{suite.tmux}, target)
+	suite.Nil(err)
+	suite.Len(report.Transferred, 0)
+
+	_, err = os.Stat(filepath.Join(suite.workDir, ".tmux.conf"))
+	suite.Nil(err)
+}
+
+func (suite *GitSuite) TestPushSkipsEncrypted() {
+	file.Afs.WriteFile("/home/dknite/.netrc", []byte("machine example.com password hunter2\n"), 0644)
+	netrc, _ := file.NewDotFile("/home/dknite/.netrc", "netrc", true)
+	netrc.SetEncrypted(true)
+	target, err := NewGitTarget("git", suite.remote, "", suite.workDir)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	report, err := PushFiles([]*file.DotFile{suite.tmux, netrc}, target)
+	suite.Nil(err)
+	suite.Nil(target.Close())
+	suite.Equal([]string{".tmux.conf"}, report.Transferred)
+	suite.Equal([]string{".netrc"}, report.Encrypted)
+
+	remote := git.Open(suite.remote)
+	out, err := remote.Run("ls-tree", "-r", "--name-only", "main")
+	suite.Nil(err)
+	suite.Equal(".tmux.conf\n", out)
+	_, err = remote.Run("grep", "hunter2", "main")
+	suite.NotNil(err)
+}
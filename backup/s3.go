@@ -0,0 +1,164 @@
+package backup
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/RedDocMD/dotted/config"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultRegion = "us-east-1"
+	// Object metadata holding the SHA1 of the contents, as
+	// canonicalised by the SDK.
+	checksumMetadata = "Sha1"
+)
+
+// S3Target mirrors the store into a bucket of an S3-compatible object
+// storage. Credentials are taken from the environment or the shared
+// AWS configuration files.
+type S3Target struct {
+	name   string
+	bucket string
+	prefix string
+	client *s3.S3
+}
+
+func NewS3Target(entry config.TargetEntry) (*S3Target, error) {
+	region := entry.Region
+	if len(region) == 0 {
+		region = defaultRegion
+	}
+	awsConfig := aws.NewConfig().
+		WithRegion(region).
+		WithS3ForcePathStyle(entry.PathStyle)
+	if len(entry.Endpoint) != 0 {
+		awsConfig = awsConfig.WithEndpoint(entry.Endpoint)
+	}
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open target %s", entry.Name)
+	}
+	prefix := strings.Trim(entry.Prefix, "/")
+	if len(prefix) != 0 {
+		prefix += "/"
+	}
+	target := &S3Target{
+		name:   entry.Name,
+		bucket: entry.Bucket,
+		prefix: prefix,
+		client: s3.New(sess),
+	}
+	return target, nil
+}
+
+func (target *S3Target) Name() string {
+	return target.name
+}
+
+func (target *S3Target) List() ([]FileInfo, error) {
+	var keys []string
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(target.bucket),
+		Prefix: aws.String(target.prefix),
+	}
+	err := target.client.ListObjectsV2Pages(input, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, object := range page.Contents {
+			keys = append(keys, aws.StringValue(object.Key))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list %s", target.name)
+	}
+	var infos []FileInfo
+	for _, key := range keys {
+		info, err := target.Stat(strings.TrimPrefix(key, target.prefix))
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (target *S3Target) Stat(path string) (FileInfo, error) {
+	output, err := target.client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(target.bucket),
+		Key:    aws.String(target.prefix + path),
+	})
+	if isNotFound(err) {
+		return FileInfo{}, FileNotFound
+	} else if err != nil {
+		return FileInfo{}, errors.Wrapf(err, "failed to stat %s", path)
+	}
+	info := FileInfo{
+		Path: path,
+		Size: aws.Int64Value(output.ContentLength),
+	}
+	if sum, ok := output.Metadata[checksumMetadata]; ok {
+		info.Checksum = aws.StringValue(sum)
+	} else {
+		// Uploaded by something else, so the checksum has to be
+		// computed from the contents.
+		data, err := target.Pull(path)
+		if err != nil {
+			return FileInfo{}, err
+		}
+		info.Checksum = checksum(data)
+	}
+	return info, nil
+}
+
+func (target *S3Target) Push(path string, data []byte) error {
+	_, err := target.client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(target.bucket),
+		Key:    aws.String(target.prefix + path),
+		Body:   bytes.NewReader(data),
+		Metadata: map[string]*string{
+			checksumMetadata: aws.String(checksum(data)),
+		},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to push %s", path)
+	}
+	return nil
+}
+
+func (target *S3Target) Pull(path string) ([]byte, error) {
+	output, err := target.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(target.bucket),
+		Key:    aws.String(target.prefix + path),
+	})
+	if isNotFound(err) {
+		return nil, FileNotFound
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to pull %s", path)
+	}
+	defer output.Body.Close()
+	data, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to pull %s", path)
+	}
+	return data, nil
+}
+
+func (target *S3Target) Close() error {
+	return nil
+}
+
+func isNotFound(err error) bool {
+	if awsErr, ok := err.(awserr.Error); ok {
+		switch awsErr.Code() {
+		case s3.ErrCodeNoSuchKey, "NotFound":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package backup
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RedDocMD/dotted/config"
+	"github.com/RedDocMD/dotted/fs"
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/stretchr/testify/suite"
+)
+
+type S3Suite struct {
+	suite.Suite
+	server  *httptest.Server
+	backend *s3mem.Backend
+	target  *S3Target
+}
+
+func (suite *S3Suite) SetupSuite() {
+	Fs = fs.MockFs
+	Afs = fs.MockAfs
+}
+
+func (suite *S3Suite) TearDownSuite() {
+	Fs = fs.OsFs
+	Afs = fs.OsAfs
+}
+
+func (suite *S3Suite) SetupTest() {
+	suite.backend = s3mem.New()
+	suite.server = httptest.NewServer(gofakes3.New(suite.backend).Server())
+	suite.backend.CreateBucket("dotfiles")
+	suite.T().Setenv("AWS_ACCESS_KEY_ID", "dknite")
+	suite.T().Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	var err error
+	suite.target, err = NewS3Target(config.TargetEntry{
+		Name:      "s3",
+		Type:      config.S3Target,
+		Bucket:    "dotfiles",
+		Prefix:    "laptop",
+		Endpoint:  suite.server.URL,
+		PathStyle: true,
+	})
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	Afs.MkdirAll("/store/97aa776c8b768a52732c7978fd5f0af5ce5a1135", 0755)
+	Afs.WriteFile("/store/paths", []byte(".tmux.conf\n"), 0644)
+	Afs.WriteFile("/store/97aa776c8b768a52732c7978fd5f0af5ce5a1135/content", []byte("set -g mouse on\n"), 0644)
+	Afs.WriteFile("/store/97aa776c8b768a52732c7978fd5f0af5ce5a1135/metadata", []byte("{}"), 0644)
+}
+
+func (suite *S3Suite) TearDownTest() {
+	suite.server.Close()
+	Afs.RemoveAll("/")
+}
+
+func TestS3Suite(t *testing.T) {
+	suite.Run(t, &S3Suite{})
+}
+
+func (suite *S3Suite) TestPushSkipsUnchanged() {
+	report, err := PushStore("/store", suite.target)
+	suite.Nil(err)
+	suite.Len(report.Transferred, 3)
+
+	object, err := suite.backend.HeadObject("dotfiles", "laptop/paths")
+	suite.Nil(err)
+	suite.Equal(int64(11), object.Size)
+
+	Afs.WriteFile("/store/97aa776c8b768a52732c7978fd5f0af5ce5a1135/content", []byte("set -g mouse off\n"), 0644)
+	report, err = PushStore("/store", suite.target)
+	suite.Nil(err)
+	suite.Equal([]string{"97aa776c8b768a52732c7978fd5f0af5ce5a1135/content"}, report.Transferred)
+	suite.Equal(2, report.Unchanged)
+}
+
+func (suite *S3Suite) TestPullRestoresStore() {
+	_, err := PushStore("/store", suite.target)
+	suite.Nil(err)
+	Afs.RemoveAll("/store")
+
+	report, err := PullStore("/store", suite.target)
+	suite.Nil(err)
+	suite.Len(report.Transferred, 3)
+	data, err := Afs.ReadFile("/store/97aa776c8b768a52732c7978fd5f0af5ce5a1135/content")
+	suite.Nil(err)
+	suite.Equal("set -g mouse on\n", string(data))
+
+	_, err = suite.target.Stat("missing")
+	suite.ErrorIs(err, FileNotFound)
+	_, err = suite.target.Pull("missing")
+	suite.ErrorIs(err, FileNotFound)
+}
@@ -0,0 +1,256 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/RedDocMD/dotted/config"
+	"github.com/pkg/errors"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	defaultSSHPort = 22
+	// Kept in the remote directory to avoid reading every file
+	// to find out whether it changed.
+	manifestName = ".dotted-manifest"
+)
+
+// SFTPTarget mirrors the store into a directory on a remote host
+// over SFTP. The host key must be listed in a known_hosts file.
+type SFTPTarget struct {
+	name      string
+	remoteDir string
+	conn      *ssh.Client
+	client    *sftp.Client
+	manifest  map[string]manifestEntry
+	dirty     bool
+}
+
+type manifestEntry struct {
+	Size     int64
+	ModTime  int64 // Unix time of the remote file
+	Checksum string
+}
+
+func NewSFTPTarget(entry config.TargetEntry) (*SFTPTarget, error) {
+	knownHostsPath := entry.KnownHosts
+	if len(knownHostsPath) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to open target %s", entry.Name)
+		}
+		knownHostsPath = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	hostKeyCallback, err := knownhosts.New(knownHostsPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open target %s: cannot verify host keys", entry.Name)
+	}
+	keyBytes, err := os.ReadFile(entry.KeyPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open target %s", entry.Name)
+	}
+	signer, err := ssh.ParsePrivateKey(keyBytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open target %s: invalid key %s", entry.Name, entry.KeyPath)
+	}
+	port := entry.Port
+	if port == 0 {
+		port = defaultSSHPort
+	}
+	sshConfig := &ssh.ClientConfig{
+		User:            entry.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+	}
+	address := net.JoinHostPort(entry.Host, strconv.Itoa(port))
+	conn, err := ssh.Dial("tcp", address, sshConfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open target %s", entry.Name)
+	}
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrapf(err, "failed to open target %s", entry.Name)
+	}
+	target := &SFTPTarget{
+		name:      entry.Name,
+		remoteDir: entry.RemoteDir,
+		conn:      conn,
+		client:    client,
+	}
+	if err = target.readManifest(); err != nil {
+		target.Close()
+		return nil, errors.WithMessagef(err, "failed to open target %s", entry.Name)
+	}
+	return target, nil
+}
+
+func (target *SFTPTarget) Name() string {
+	return target.name
+}
+
+func (target *SFTPTarget) List() ([]FileInfo, error) {
+	var infos []FileInfo
+	if _, err := target.client.Stat(target.remoteDir); os.IsNotExist(err) {
+		return infos, nil
+	}
+	walker := target.client.Walk(target.remoteDir)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			return nil, errors.Wrapf(err, "failed to list %s", target.name)
+		}
+		if !walker.Stat().Mode().IsRegular() {
+			continue
+		}
+		relPath := strings.TrimPrefix(walker.Path(), strings.TrimSuffix(target.remoteDir, "/")+"/")
+		if relPath == manifestName || strings.HasSuffix(relPath, ".tmp") {
+			continue
+		}
+		info, err := target.fileInfo(relPath, walker.Stat())
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (target *SFTPTarget) Stat(relPath string) (FileInfo, error) {
+	stat, err := target.client.Stat(target.fullPath(relPath))
+	if os.IsNotExist(err) {
+		return FileInfo{}, FileNotFound
+	} else if err != nil {
+		return FileInfo{}, errors.Wrapf(err, "failed to stat %s", relPath)
+	}
+	return target.fileInfo(relPath, stat)
+}
+
+// fileInfo uses the checksum in the manifest if the file has not
+// been touched since it was recorded, and reads the file otherwise.
+func (target *SFTPTarget) fileInfo(relPath string, stat os.FileInfo) (FileInfo, error) {
+	entry, ok := target.manifest[relPath]
+	if !ok || entry.Size != stat.Size() || entry.ModTime != stat.ModTime().Unix() {
+		data, err := target.Pull(relPath)
+		if err != nil {
+			return FileInfo{}, err
+		}
+		entry = manifestEntry{
+			Size:     stat.Size(),
+			ModTime:  stat.ModTime().Unix(),
+			Checksum: checksum(data),
+		}
+		target.manifest[relPath] = entry
+		target.dirty = true
+	}
+	info := FileInfo{
+		Path:     relPath,
+		Size:     entry.Size,
+		Checksum: entry.Checksum,
+	}
+	return info, nil
+}
+
+func (target *SFTPTarget) Push(relPath string, data []byte) error {
+	if err := target.writeFile(target.fullPath(relPath), data); err != nil {
+		return errors.Wrapf(err, "failed to push %s", relPath)
+	}
+	stat, err := target.client.Stat(target.fullPath(relPath))
+	if err != nil {
+		return errors.Wrapf(err, "failed to push %s", relPath)
+	}
+	target.manifest[relPath] = manifestEntry{
+		Size:     stat.Size(),
+		ModTime:  stat.ModTime().Unix(),
+		Checksum: checksum(data),
+	}
+	target.dirty = true
+	return nil
+}
+
+func (target *SFTPTarget) Pull(relPath string) ([]byte, error) {
+	remoteFile, err := target.client.Open(target.fullPath(relPath))
+	if os.IsNotExist(err) {
+		return nil, FileNotFound
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to pull %s", relPath)
+	}
+	defer remoteFile.Close()
+	data, err := io.ReadAll(remoteFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to pull %s", relPath)
+	}
+	return data, nil
+}
+
+// Close saves the manifest if it changed and closes the connection.
+func (target *SFTPTarget) Close() error {
+	var err error
+	if target.dirty {
+		err = target.writeManifest()
+	}
+	target.client.Close()
+	target.conn.Close()
+	return err
+}
+
+func (target *SFTPTarget) readManifest() error {
+	target.manifest = make(map[string]manifestEntry)
+	data, err := target.Pull(manifestName)
+	if errors.Is(err, FileNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(data, &target.manifest); err != nil {
+		// The manifest is only a cache, so rebuild it
+		target.manifest = make(map[string]manifestEntry)
+	}
+	return nil
+}
+
+func (target *SFTPTarget) writeManifest() error {
+	data, err := json.Marshal(target.manifest)
+	if err != nil {
+		return errors.Wrap(err, "failed to save manifest")
+	}
+	if err = target.writeFile(target.fullPath(manifestName), data); err != nil {
+		return errors.Wrap(err, "failed to save manifest")
+	}
+	return nil
+}
+
+func (target *SFTPTarget) writeFile(fullPath string, data []byte) error {
+	if err := target.client.MkdirAll(path.Dir(fullPath)); err != nil {
+		return err
+	}
+	tmpPath := fullPath + ".tmp"
+	remoteFile, err := target.client.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if _, err = remoteFile.Write(data); err != nil {
+		remoteFile.Close()
+		return err
+	}
+	if err = remoteFile.Close(); err != nil {
+		return err
+	}
+	if err = target.client.PosixRename(tmpPath, fullPath); err != nil {
+		return fmt.Errorf("failed to replace %s: %v", fullPath, err)
+	}
+	return nil
+}
+
+func (target *SFTPTarget) fullPath(relPath string) string {
+	return path.Join(target.remoteDir, relPath)
+}
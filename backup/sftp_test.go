@@ -0,0 +1,193 @@
+package backup
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/RedDocMD/dotted/config"
+	"github.com/RedDocMD/dotted/fs"
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+type SFTPSuite struct {
+	suite.Suite
+	listener net.Listener
+	entry    config.TargetEntry
+	hostKey  ssh.Signer
+}
+
+func (suite *SFTPSuite) SetupSuite() {
+	Fs = fs.MockFs
+	Afs = fs.MockAfs
+}
+
+func (suite *SFTPSuite) TearDownSuite() {
+	Fs = fs.OsFs
+	Afs = fs.OsAfs
+}
+
+func (suite *SFTPSuite) SetupTest() {
+	tmpDir := suite.T().TempDir()
+	var clientKey ssh.Signer
+	suite.hostKey, _ = newSigner(filepath.Join(tmpDir, "host_key"))
+	clientKey, keyPath := newSigner(filepath.Join(tmpDir, "id_ecdsa"))
+	suite.listener = serveSFTP(suite.T(), suite.hostKey, clientKey.PublicKey())
+
+	host, port, _ := net.SplitHostPort(suite.listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	knownHostsPath := filepath.Join(tmpDir, "known_hosts")
+	line := knownhosts.Line([]string{suite.listener.Addr().String()}, suite.hostKey.PublicKey())
+	os.WriteFile(knownHostsPath, []byte(line+"\n"), 0600)
+	suite.entry = config.TargetEntry{
+		Name:       "server",
+		Type:       config.SFTPTarget,
+		Host:       host,
+		Port:       portNumber,
+		User:       "dknite",
+		KeyPath:    keyPath,
+		KnownHosts: knownHostsPath,
+		RemoteDir:  filepath.ToSlash(filepath.Join(tmpDir, "remote", "store")),
+	}
+
+	Afs.MkdirAll("/store/97aa776c8b768a52732c7978fd5f0af5ce5a1135", 0755)
+	Afs.WriteFile("/store/paths", []byte(".tmux.conf\n"), 0644)
+	Afs.WriteFile("/store/97aa776c8b768a52732c7978fd5f0af5ce5a1135/content", []byte("set -g mouse on\n"), 0644)
+	Afs.WriteFile("/store/97aa776c8b768a52732c7978fd5f0af5ce5a1135/metadata", []byte("{}"), 0644)
+}
+
+func (suite *SFTPSuite) TearDownTest() {
+	suite.listener.Close()
+	Afs.RemoveAll("/")
+}
+
+func TestSFTPSuite(t *testing.T) {
+	suite.Run(t, &SFTPSuite{})
+}
+
+func newSigner(keyPath string) (ssh.Signer, string) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalECPrivateKey(key)
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	os.WriteFile(keyPath, keyPem, 0600)
+	signer, _ := ssh.NewSignerFromKey(key)
+	return signer, keyPath
+}
+
+// serveSFTP starts an SSH server on localhost which only accepts
+// clientKey and serves the local filesystem over SFTP.
+func serveSFTP(t *testing.T, hostKey ssh.Signer, clientKey ssh.PublicKey) net.Listener {
+	serverConfig := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) == string(clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, os.ErrPermission
+		},
+	}
+	serverConfig.AddHostKey(hostKey)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			netConn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSSHConn(netConn, serverConfig)
+		}
+	}()
+	return listener
+}
+
+func serveSSHConn(netConn net.Conn, serverConfig *ssh.ServerConfig) {
+	_, channels, requests, err := ssh.NewServerConn(netConn, serverConfig)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func(in <-chan *ssh.Request) {
+			for req := range in {
+				req.Reply(req.Type == "subsystem" && string(req.Payload[4:]) == "sftp", nil)
+			}
+		}(requests)
+		server, err := sftp.NewServer(channel)
+		if err != nil {
+			return
+		}
+		go func() {
+			server.Serve()
+			server.Close()
+		}()
+	}
+}
+
+func (suite *SFTPSuite) openTarget() *SFTPTarget {
+	target, err := NewSFTPTarget(suite.entry)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	return target
+}
+
+func (suite *SFTPSuite) TestPushAndPull() {
+	target := suite.openTarget()
+	report, err := PushStore("/store", target)
+	suite.Nil(err)
+	suite.Len(report.Transferred, 3)
+	suite.Nil(target.Close())
+
+	data, err := os.ReadFile(filepath.Join(suite.entry.RemoteDir, "paths"))
+	suite.Nil(err)
+	suite.Equal(".tmux.conf\n", string(data))
+
+	target = suite.openTarget()
+	report, err = PushStore("/store", target)
+	suite.Nil(err)
+	suite.Len(report.Transferred, 0)
+	suite.Equal(3, report.Unchanged)
+	suite.Nil(target.Close())
+
+	Afs.RemoveAll("/store")
+	target = suite.openTarget()
+	report, err = PullStore("/store", target)
+	suite.Nil(err)
+	suite.Len(report.Transferred, 3)
+	suite.Nil(target.Close())
+	data, err = Afs.ReadFile("/store/97aa776c8b768a52732c7978fd5f0af5ce5a1135/content")
+	suite.Nil(err)
+	suite.Equal("set -g mouse on\n", string(data))
+}
+
+func (suite *SFTPSuite) TestRejectsUnknownHostKey() {
+	otherKey, _ := newSigner(filepath.Join(suite.T().TempDir(), "other_key"))
+	line := knownhosts.Line([]string{suite.listener.Addr().String()}, otherKey.PublicKey())
+	os.WriteFile(suite.entry.KnownHosts, []byte(line+"\n"), 0600)
+	_, err := NewSFTPTarget(suite.entry)
+	suite.NotNil(err)
+
+	suite.entry.KnownHosts = filepath.Join(suite.T().TempDir(), "missing")
+	_, err = NewSFTPTarget(suite.entry)
+	suite.NotNil(err)
+}
@@ -0,0 +1,114 @@
+package backup
+
+import (
+	"fmt"
+
+	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/store"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// FileSync describes how the history of one dot-file changed
+// while syncing.
+type FileSync struct {
+	DotFile *file.DotFile
+	Added   int
+	// Current node of the file on the target
+	RemoteTip *file.HistoryNode
+	// Set when the local current node is an ancestor of RemoteTip,
+	// so the file needs a checkout of RemoteTip
+	Behind bool
+	// Set when neither of the local current node and RemoteTip is an
+	// ancestor of the other, so the file has tips which need a merge
+	Diverged bool
+	// Tags on the target which the file has on other commits, which
+	// are kept where they are
+	TagClashes []file.TagClash
+}
+
+// SyncConflict is a dot-file whose history on the target cannot be
+// merged with the local one, such as a history started separately
+// on each machine.
+type SyncConflict struct {
+	DotFile *file.DotFile
+	Err     error
+}
+
+// SyncReport summarises a sync with a target.
+type SyncReport struct {
+	Files []FileSync
+	// Files on the target which have no history, or which this
+	// machine does not track
+	Skipped []string
+	// Files which are left as they are, both locally and on the target
+	Conflicts []SyncConflict
+	Pushed    Report
+}
+
+// Sync merges the history of every dot-file in fileStore with its
+// copy on target, then pushes the merged store back. The store must
+// have been saved to disk beforehand and is saved again before the
+// push, so it need not be reloaded afterwards.
+//
+// The target keeps listing the files which only other machines track,
+// so that they still sync with it. Deletions are not synced: nothing
+// is deleted from the target, so a file which one machine stops
+// tracking, or whose history it drops or purges, stays on the target
+// as it was. A file whose histories conflict is reported and left
+// alone on both sides, and the other files are still synced.
+func Sync(fileStore *store.Store, target Target) (SyncReport, error) {
+	var report SyncReport
+	if _, ok := target.(FileTarget); ok {
+		return report, fmt.Errorf("failed to sync with %s: target does not hold the store", target.Name())
+	}
+	stagingDir, err := afero.TempDir(Afs, "", "dotted-sync")
+	if err != nil {
+		return report, errors.Wrapf(err, "failed to sync with %s", target.Name())
+	}
+	defer Afs.RemoveAll(stagingDir)
+	if _, err = PullStore(stagingDir, target); err != nil {
+		return report, errors.WithMessagef(err, "failed to sync with %s", target.Name())
+	}
+	remoteFiles, err := store.LoadFilesFromDisk(stagingDir)
+	if err != nil {
+		return report, errors.WithMessagef(err, "failed to sync with %s", target.Name())
+	}
+	localFiles := make(map[string]*file.DotFile)
+	for _, dotFile := range fileStore.Files() {
+		localFiles[dotFile.Path()] = dotFile
+	}
+	var conflicting []string
+	for _, remoteFile := range remoteFiles {
+		localFile, ok := localFiles[remoteFile.Path()]
+		if !ok || !localFile.HasHistory() || !remoteFile.HasHistory() {
+			report.Skipped = append(report.Skipped, remoteFile.RelativePath())
+			continue
+		}
+		result, err := localFile.Merge(remoteFile)
+		if err != nil {
+			report.Conflicts = append(report.Conflicts, SyncConflict{DotFile: localFile, Err: err})
+			conflicting = append(conflicting, localFile.RelativePathHash())
+			continue
+		}
+		report.Files = append(report.Files, FileSync{
+			DotFile:    localFile,
+			Added:      result.Added,
+			RemoteTip:  result.OtherTip,
+			Behind:     result.Behind,
+			Diverged:   result.Diverged,
+			TagClashes: result.TagClashes,
+		})
+	}
+	if err = fileStore.SaveToDisk(); err != nil {
+		return report, errors.WithMessagef(err, "failed to sync with %s", target.Name())
+	}
+	if err = store.MergeManifests(stagingDir, fileStore.Path()); err != nil {
+		return report, errors.WithMessagef(err, "failed to sync with %s", target.Name())
+	}
+	report.Pushed, err = pushStore(fileStore.Path(), stagingDir, conflicting, target)
+	if err != nil {
+		return report, errors.WithMessagef(err, "failed to sync with %s", target.Name())
+	}
+	return report, nil
+}
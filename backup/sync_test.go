@@ -0,0 +1,236 @@
+package backup
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/RedDocMD/dotted/config"
+	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/fs"
+	"github.com/RedDocMD/dotted/store"
+	"github.com/stretchr/testify/suite"
+)
+
+type SyncSuite struct {
+	suite.Suite
+	config *config.Config
+}
+
+func (suite *SyncSuite) SetupSuite() {
+	Fs, file.Fs, store.Fs = fs.MockFs, fs.MockFs, fs.MockFs
+	Afs, file.Afs, store.Afs = fs.MockAfs, fs.MockAfs, fs.MockAfs
+}
+
+func (suite *SyncSuite) TearDownSuite() {
+	Fs, file.Fs, store.Fs = fs.OsFs, fs.OsFs, fs.OsFs
+	Afs, file.Afs, store.Afs = fs.OsAfs, fs.OsAfs, fs.OsAfs
+}
+
+func (suite *SyncSuite) SetupTest() {
+	Afs.MkdirAll("/home/dknite", 0755)
+	Afs.MkdirAll("/tmp", 0755)
+	Afs.WriteFile("/home/dknite/.tmux.conf", []byte("set -g mouse on\n"), 0644)
+	suite.config = &config.Config{
+		Name: "Linux",
+		WithHistory: []config.FileEntry{
+			{Path: ".tmux.conf", Mnemonic: "tmux"},
+		},
+		StoreLocation: "/laptop/store",
+	}
+}
+
+func (suite *SyncSuite) TearDownTest() {
+	Afs.RemoveAll("/")
+}
+
+func TestSyncSuite(t *testing.T) {
+	suite.Run(t, &SyncSuite{})
+}
+
+func (suite *SyncSuite) loadStore(location string) *store.Store {
+	suite.config.StoreLocation = location
+	fileStore, err := store.LoadFromDisk(suite.config)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	return fileStore
+}
+
+func (suite *SyncSuite) TestSyncReportsDivergedTips() {
+	target := NewDirTarget("drive", "/mnt/drive")
+	laptop := suite.loadStore("/laptop/store")
+	suite.Nil(laptop.SaveToDisk())
+	_, err := PushStore("/laptop/store", target)
+	suite.Nil(err)
+
+	// The desktop starts from the laptop's store and commits
+	_, err = PullStore("/desktop/store", target)
+	suite.Nil(err)
+	desktop := suite.loadStore("/desktop/store")
+	Afs.WriteFile("/home/dknite/.tmux.conf", []byte("set -g mouse off\n"), 0644)
+	_, err = desktop.Files()[0].AddCommit()
+	suite.Nil(err)
+	suite.Nil(desktop.SaveToDisk())
+	report, err := Sync(desktop, target)
+	suite.Nil(err)
+	suite.Len(report.Files, 1)
+	suite.False(report.Files[0].Diverged)
+
+	// Meanwhile the laptop committed something else
+	Afs.WriteFile("/home/dknite/.tmux.conf", []byte("set -g mouse on\nset -g base-index 1\n"), 0644)
+	_, err = laptop.Files()[0].AddCommit()
+	suite.Nil(err)
+	suite.Nil(laptop.SaveToDisk())
+	report, err = Sync(laptop, target)
+	suite.Nil(err)
+	suite.Len(report.Files, 1)
+	suite.Equal(1, report.Files[0].Added)
+	suite.False(report.Files[0].Behind)
+	suite.True(report.Files[0].Diverged)
+	suite.Equal("set -g mouse off\n", report.Files[0].RemoteTip.Content())
+	suite.Len(laptop.Files()[0].HistoryRoot().Leaves(), 2)
+
+	// The target now holds both tips
+	merged, err := store.LoadFilesFromDisk("/mnt/drive")
+	suite.Nil(err)
+	suite.Len(merged[0].HistoryRoot().Leaves(), 2)
+}
+
+func (suite *SyncSuite) TestSyncReportsBehind() {
+	target := NewDirTarget("drive", "/mnt/drive")
+	laptop := suite.loadStore("/laptop/store")
+	suite.Nil(laptop.SaveToDisk())
+	_, err := PushStore("/laptop/store", target)
+	suite.Nil(err)
+
+	_, err = PullStore("/desktop/store", target)
+	suite.Nil(err)
+	desktop := suite.loadStore("/desktop/store")
+	Afs.WriteFile("/home/dknite/.tmux.conf", []byte("set -g mouse off\n"), 0644)
+	_, err = desktop.Files()[0].AddCommit()
+	suite.Nil(err)
+	suite.Nil(desktop.SaveToDisk())
+	_, err = Sync(desktop, target)
+	suite.Nil(err)
+
+	// The laptop has not committed since, so it only needs a checkout
+	report, err := Sync(laptop, target)
+	suite.Nil(err)
+	suite.Len(report.Files, 1)
+	suite.Equal(1, report.Files[0].Added)
+	suite.True(report.Files[0].Behind)
+	suite.False(report.Files[0].Diverged)
+	suite.Equal("set -g mouse off\n", report.Files[0].RemoteTip.Content())
+}
+
+func (suite *SyncSuite) TestSyncKeepsFilesOfOtherMachines() {
+	target := NewDirTarget("drive", "/mnt/drive")
+	laptop := suite.loadStore("/laptop/store")
+	suite.Nil(laptop.SaveToDisk())
+	_, err := Sync(laptop, target)
+	suite.Nil(err)
+
+	// The desktop only tracks fish
+	Afs.MkdirAll("/home/dknite/.config/fish", 0755)
+	Afs.WriteFile("/home/dknite/.config/fish/config.fish", []byte("set -x EDITOR nvim\n"), 0644)
+	suite.config.WithHistory = []config.FileEntry{{Path: ".config/fish/config.fish", Mnemonic: "fish"}}
+	desktop := suite.loadStore("/desktop/store")
+	suite.Nil(desktop.SaveToDisk())
+	report, err := Sync(desktop, target)
+	suite.Nil(err)
+	suite.Len(report.Files, 0)
+	suite.Equal([]string{".tmux.conf"}, report.Skipped)
+
+	remote, err := store.LoadFilesFromDisk("/mnt/drive")
+	suite.Nil(err)
+	suite.Len(remote, 2)
+
+	// Both machines keep syncing their own file
+	Afs.WriteFile("/home/dknite/.tmux.conf", []byte("set -g mouse off\n"), 0644)
+	_, err = laptop.Files()[0].AddCommit()
+	suite.Nil(err)
+	suite.Nil(laptop.SaveToDisk())
+	report, err = Sync(laptop, target)
+	suite.Nil(err)
+	suite.Len(report.Files, 1)
+	suite.Equal([]string{".config/fish/config.fish"}, report.Skipped)
+
+	remote, err = store.LoadFilesFromDisk("/mnt/drive")
+	suite.Nil(err)
+	suite.Len(remote, 2)
+	for _, dotFile := range remote {
+		if dotFile.Path() == "/home/dknite/.tmux.conf" {
+			suite.Equal("set -g mouse off\n", dotFile.Content())
+		}
+	}
+	paths, err := Afs.ReadFile("/laptop/store/paths")
+	suite.Nil(err)
+	suite.Equal(".tmux.conf\n", string(paths))
+}
+
+func (suite *SyncSuite) TestSyncSkipsIndependentHistories() {
+	target := NewDirTarget("drive", "/mnt/drive")
+	laptop := suite.loadStore("/laptop/store")
+	suite.Nil(laptop.SaveToDisk())
+	_, err := Sync(laptop, target)
+	suite.Nil(err)
+
+	// The desktop starts its own history of tmux, and one of fish
+	Afs.MkdirAll("/home/dknite/.config/fish", 0755)
+	Afs.WriteFile("/home/dknite/.config/fish/config.fish", []byte("set -x EDITOR nvim\n"), 0644)
+	suite.config.WithHistory = append(suite.config.WithHistory,
+		config.FileEntry{Path: ".config/fish/config.fish", Mnemonic: "fish"})
+	desktop := suite.loadStore("/desktop/store")
+	suite.Nil(desktop.SaveToDisk())
+	report, err := Sync(desktop, target)
+	suite.Nil(err)
+	suite.Len(report.Files, 0)
+	suite.Len(report.Conflicts, 1)
+	suite.Equal("/home/dknite/.tmux.conf", report.Conflicts[0].DotFile.Path())
+	suite.Contains(report.Conflicts[0].Err.Error(), "unrelated roots")
+
+	// The target keeps the laptop's tmux and gains fish
+	remote, err := store.LoadFilesFromDisk("/mnt/drive")
+	suite.Nil(err)
+	suite.Len(remote, 2)
+	for _, dotFile := range remote {
+		if dotFile.Path() == "/home/dknite/.tmux.conf" {
+			suite.Equal(laptop.Files()[0].HistoryRoot().UUID(), dotFile.HistoryRoot().UUID())
+		}
+	}
+}
+
+func (suite *SyncSuite) TestSyncSkipsDifferentContent() {
+	target := NewDirTarget("drive", "/mnt/drive")
+	laptop := suite.loadStore("/laptop/store")
+	suite.Nil(laptop.SaveToDisk())
+	_, err := Sync(laptop, target)
+	suite.Nil(err)
+
+	// The commit they share has other content on the target
+	historyPath := "/mnt/drive/" + laptop.Files()[0].RelativePathHash() + "/history"
+	history, err := Afs.ReadFile(historyPath)
+	suite.Nil(err)
+	checksum := fmt.Sprintf("%x", sha1.Sum([]byte("set -g mouse on\n")))
+	suite.Contains(string(history), checksum)
+	corrupted := strings.ReplaceAll(string(history), checksum, strings.Repeat("0", 40))
+	Afs.WriteFile(historyPath, []byte(corrupted), 0644)
+
+	Afs.WriteFile("/home/dknite/.tmux.conf", []byte("set -g mouse off\n"), 0644)
+	_, err = laptop.Files()[0].AddCommit()
+	suite.Nil(err)
+	suite.Nil(laptop.SaveToDisk())
+	report, err := Sync(laptop, target)
+	suite.Nil(err)
+	suite.Len(report.Files, 0)
+	suite.Len(report.Conflicts, 1)
+	suite.Contains(report.Conflicts[0].Err.Error(), "different content")
+
+	// Neither side is changed
+	suite.Len(laptop.Files()[0].HistoryRoot().Leaves(), 1)
+	history, err = Afs.ReadFile(historyPath)
+	suite.Nil(err)
+	suite.Equal(corrupted, string(history))
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/RedDocMD/dotted/config"
+	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/output"
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var addCmd = &cobra.Command{
+	Use:   "add <path>",
+	Short: "add a dot-file to the config and the store",
+	Long: `Adds the file at path, which must be under $HOME, to the config and
+the store. A file which was archived after its entry left the config
+is restored with its history and the entries it had in the config,
+ignoring the flags.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := filepath.Abs(args[0])
+		if err != nil {
+			return errors.Wrap(err, "failed to add")
+		}
+		relPath, err := filepath.Rel(file.Fs.UserHomeDir(), path)
+		if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("failed to add %s: not under $HOME", path)
+		}
+		if _, err = dotFileByPath(fileStore.Files(), path); err == nil {
+			return fmt.Errorf("failed to add %s: already in the store", path)
+		}
+		entry := config.FileEntry{Path: filepath.ToSlash(relPath), Mnemonic: addMnemonic}
+
+		for _, archived := range fileStore.Archived() {
+			if archived != entry.Path {
+				continue
+			}
+			if cmd.Flags().Changed("mnemonic") || addNoHistory {
+				color.Yellow("Warning: flags are ignored when restoring an archived file")
+			}
+			dotFile, entries, err := fileStore.Restore(archived)
+			if err != nil {
+				return errors.WithMessage(err, "failed to add")
+			}
+			if len(entries.WithHistory) == 0 && len(entries.WithoutHistory) == 0 {
+				// Archived before its entries were saved with it
+				entry.Mnemonic = dotFile.Mnemonic()
+				entry.Encrypted = dotFile.IsEncrypted()
+				if dotFile.HasHistory() {
+					entries.WithHistory = []config.FileEntry{entry}
+				} else {
+					entries.WithoutHistory = []config.FileEntry{entry}
+				}
+			}
+			for _, restored := range entries.WithHistory {
+				if err = config.AddEntry(configPath, restored, true); err != nil {
+					return errors.WithMessage(err, "failed to add")
+				}
+			}
+			for _, restored := range entries.WithoutHistory {
+				if err = config.AddEntry(configPath, restored, false); err != nil {
+					return errors.WithMessage(err, "failed to add")
+				}
+			}
+			color.Green("Restored %s", path)
+			if output.IsStructured() {
+				return output.Print(output.NewFile(dotFile, configs.StoreLocation))
+			}
+			return nil
+		}
+
+		dotFile, err := file.NewDotFile(path, entry.Mnemonic, !addNoHistory)
+		if err != nil {
+			return errors.WithMessage(err, "failed to add")
+		}
+		if err = config.AddEntry(configPath, entry, !addNoHistory); err != nil {
+			return errors.WithMessage(err, "failed to add")
+		}
+		if err = fileStore.AddFile(dotFile); err != nil {
+			return errors.WithMessage(err, "failed to add")
+		}
+		color.Green("Added %s", path)
+		if output.IsStructured() {
+			return output.Print(output.NewFile(dotFile, configs.StoreLocation))
+		}
+		return nil
+	},
+}
+
+var addMnemonic string
+var addNoHistory bool
+
+func initAddCommand() {
+	addCmd.Flags().StringVar(&addMnemonic, "mnemonic", "", "mnemonic for the file")
+	addCmd.Flags().BoolVar(&addNoHistory, "no-history", false,
+		"add the file to withoutHistory instead of withHistory")
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/RedDocMD/dotted/deploy"
+	"github.com/RedDocMD/dotted/output"
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply [<path>|<mnemonic>]*",
+	Short: "write the stored version of dot-files to their paths",
+	Long: `Writes every dot-file, or only the ones given, to its path with the
+content of its current version, creating parent directories. A file
+which differs from the stored version is first moved aside to a
+backup next to it, so nothing is lost. The preApply and postApply
+hooks run around every file which is written.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dotFiles := fileStore.Files()
+		if len(args) != 0 {
+			var err error
+			dotFiles, err = dotFilesByArgs(dotFiles, args)
+			if err != nil {
+				return errors.WithMessage(err, "failed to apply")
+			}
+		}
+		applied, stopped := 0, 0
+		results := []output.ApplyResult{}
+		for _, dotFile := range dotFiles {
+			change, err := applyDotFile(dotFile, applyDryRun)
+			if isStopped(err) {
+				color.Red("Skipped %s: %v", dotFile.Path(), err)
+				results = append(results, output.ApplyResult{
+					Path:    dotFile.Path(),
+					Action:  output.Skipped,
+					Backups: []string{},
+					Reason:  err.Error(),
+				})
+				stopped += 1
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			printChange(change)
+			results = append(results, applyResult(change))
+			if change.Action != deploy.Unchanged {
+				applied += 1
+			}
+		}
+		if output.IsStructured() {
+			return output.Print(results)
+		}
+		if applyDryRun {
+			color.Yellow("Would apply %d of %d files", applied, len(dotFiles))
+		} else {
+			color.Green("Applied %d of %d files", applied, len(dotFiles))
+		}
+		if stopped == 1 {
+			color.Red("Skipped 1 file as a preApply hook failed")
+		} else if stopped > 1 {
+			color.Red("Skipped %d files as a preApply hook failed", stopped)
+		}
+		return nil
+	},
+}
+
+var applyDryRun bool
+
+func initApplyCommand() {
+	applyCmd.Flags().BoolVar(&applyDryRun, "dry-run", false,
+		"print what would be written without changing any file")
+}
+
+func applyResult(change deploy.Change) output.ApplyResult {
+	result := output.ApplyResult{
+		Path:    change.DotFile.Path(),
+		Action:  change.Action.String(),
+		Backups: change.Backups,
+	}
+	if result.Backups == nil {
+		result.Backups = []string{}
+	}
+	return result
+}
+
+func printChange(change deploy.Change) {
+	switch change.Action {
+	case deploy.Created:
+		fmt.Fprintf(messages, "Create %s\n", change.DotFile.Path())
+	case deploy.Replaced:
+		fmt.Fprintf(messages, "Replace %s\n", change.DotFile.Path())
+	}
+	for _, backup := range change.Backups {
+		fmt.Fprintf(messages, "  backup at %s\n", backup)
+	}
+}
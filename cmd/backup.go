@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/RedDocMD/dotted/backup"
+	"github.com/RedDocMD/dotted/output"
+	"github.com/RedDocMD/dotted/store"
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "push the store to or pull it from a backup target",
+}
+
+var backupPushCmd = &cobra.Command{
+	Use:   "push <target>",
+	Short: "copy changed files in the store to a backup target",
+	Long: `Copies the files in the store which are missing or changed on the
+target. A git target gets a readable copy of every file instead,
+except encrypted ones.
+
+Deletions are not pushed, as nothing is deleted from the target. The
+data of files purged with dtd purge, which may hold encrypted secrets,
+stays on the target until it is removed by hand.`,
+	Args: expectTargetArg,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target, err := openTarget(args[0])
+		if err != nil {
+			return err
+		}
+		if err = fileStore.SaveToDisk(); err != nil {
+			return err
+		}
+		report, err := backup.Push(fileStore, target)
+		if err != nil {
+			target.Close()
+			return err
+		}
+		if err = target.Close(); err != nil {
+			return err
+		}
+		printReport(report)
+		if output.IsStructured() {
+			return output.Print(transferOf(report, target.Name()))
+		}
+		color.Green("Pushed %d of %d files to %s", len(report.Transferred),
+			len(report.Transferred)+report.Unchanged, target.Name())
+		return nil
+	},
+}
+
+var backupPullCmd = &cobra.Command{
+	Use:   "pull <target>",
+	Short: "copy changed files from a backup target into the store",
+	Args:  expectTargetArg,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target, err := openTarget(args[0])
+		if err != nil {
+			return err
+		}
+		report, err := backup.Pull(fileStore, target)
+		if err != nil {
+			target.Close()
+			return err
+		}
+		if err = target.Close(); err != nil {
+			return err
+		}
+		if _, ok := target.(backup.FileTarget); !ok {
+			// The store in memory is stale now, so reload it before it
+			// is saved back to disk.
+			fileStore, err = store.LoadFromDisk(configs)
+			if err != nil {
+				return err
+			}
+		}
+		printReport(report)
+		if output.IsStructured() {
+			return output.Print(transferOf(report, target.Name()))
+		}
+		color.Green("Pulled %d of %d files from %s", len(report.Transferred),
+			len(report.Transferred)+report.Unchanged, target.Name())
+		return nil
+	},
+}
+
+func initBackupCommand() {
+	backupCmd.AddCommand(backupPushCmd)
+	backupCmd.AddCommand(backupPullCmd)
+}
+
+func expectTargetArg(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected exactly one target as arg")
+	}
+	return nil
+}
+
+func openTarget(name string) (backup.Target, error) {
+	entry, err := configs.TargetWithName(name)
+	if err != nil {
+		return nil, err
+	}
+	target, err := backup.New(entry)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to open backup target")
+	}
+	return target, nil
+}
+
+func printReport(report backup.Report) {
+	for _, path := range report.Transferred {
+		fmt.Fprintf(messages, "Copied %s\n", path)
+	}
+	for _, path := range report.Skipped {
+		color.Yellow("Skipped %s: changed on target but has no history", path)
+	}
+	for _, path := range report.Encrypted {
+		color.Yellow("Skipped %s: encrypted files are not copied to readable targets", path)
+	}
+}
+
+func transferOf(report backup.Report, target string) output.Transfer {
+	transfer := output.Transfer{
+		Target:      target,
+		Transferred: report.Transferred,
+		Skipped:     report.Skipped,
+		Unchanged:   report.Unchanged,
+		Encrypted:   report.Encrypted,
+	}
+	if transfer.Transferred == nil {
+		transfer.Transferred = []string{}
+	}
+	if transfer.Skipped == nil {
+		transfer.Skipped = []string{}
+	}
+	if transfer.Encrypted == nil {
+		transfer.Encrypted = []string{}
+	}
+	return transfer
+}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/RedDocMD/dotted/deploy"
 	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/output"
 	"github.com/fatih/color"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -13,6 +15,13 @@ import (
 var commitCommand = &cobra.Command{
 	Use:   "commit [<path>|<mnemonic>]+",
 	Short: "create a commit for the current version of one or more files",
+	Long: `Creates a commit for each changed file with history, and updates
+the stored content of each changed file without. A YAML, JSON or TOML
+file, found by its extension or the format of its entry, is first
+checked for broken syntax, and then the preCommit hooks run. A file
+failing either is skipped, unless --no-verify is given. JSON files
+may have comments and trailing commas, as in .vscode/settings.json,
+unless their entry has format: json.`,
 	Args: func(cmd *cobra.Command, args []string) error {
 		if allFiles && len(args) != 0 {
 			color.Yellow("Warning: Args are ignored with --all flag")
@@ -28,80 +37,126 @@ var commitCommand = &cobra.Command{
 		if allFiles {
 			dotFiles = fileStore.Files()
 		} else {
-			for _, arg := range args {
-				if strings.HasPrefix(arg, "/") {
-					file, err := dotFileByPath(fileStore.Files(), arg)
-					if err != nil {
-						return errors.WithMessage(err, "failed to commit")
-					}
-					dotFiles = append(dotFiles, file)
-				} else {
-					file, err := dotFileByMnemonic(fileStore.Files(), arg)
-					if err != nil {
-						return errors.WithMessage(err, "failed to commit")
-					}
-					dotFiles = append(dotFiles, file)
-				}
+			var err error
+			dotFiles, err = dotFilesByArgs(fileStore.Files(), args)
+			if err != nil {
+				return errors.WithMessage(err, "failed to commit")
 			}
 		}
+		dotFiles = skipUndeployed(dotFiles)
 		withHistory, withoutHistory := splitDotFilesByHistory(dotFiles)
-		commitCnt, err := commitDotFiles(withHistory)
+		results, err := commitDotFiles(append(withHistory, withoutHistory...))
 		if err != nil {
 			return err
 		}
-		updateCnt, err := updateDotFiles(withoutHistory)
-		if err != nil {
-			return err
+		if output.IsStructured() {
+			return output.Print(results)
 		}
-		if commitCnt <= 1 {
-			color.Green("Committed %d file", commitCnt)
+		counts := make(map[string]int)
+		for _, result := range results {
+			counts[result.Result] += 1
+		}
+		if counts[output.Committed] <= 1 {
+			color.Green("Committed %d file", counts[output.Committed])
 		} else {
-			color.Green("Committed %d files", commitCnt)
+			color.Green("Committed %d files", counts[output.Committed])
 		}
-		if updateCnt <= 1 {
-			color.Green("Updated %d file", updateCnt)
+		if counts[output.Updated] <= 1 {
+			color.Green("Updated %d file", counts[output.Updated])
 		} else {
-			color.Green("Updated %d files", updateCnt)
+			color.Green("Updated %d files", counts[output.Updated])
+		}
+		if stopped := counts[output.Skipped]; stopped == 1 {
+			color.Red("Skipped 1 changed file which failed a check, commit it anyway with --no-verify")
+		} else if stopped > 1 {
+			color.Red("Skipped %d changed files which failed a check, commit them anyway with --no-verify", stopped)
 		}
 		return nil
 	},
 }
 
-var allFiles bool
+var allFiles, noVerify bool
 
 func initCommitCommand() {
 	commitCommand.Flags().BoolVar(&allFiles, "all", false,
 		"commit all dot-files that have been changed")
+	commitCommand.Flags().BoolVar(&noVerify, "no-verify", false,
+		"commit files without validating their syntax or running preCommit hooks")
 }
 
-func commitDotFiles(dotFiles []*file.DotFile) (int, error) {
-	cnt := 0
+// skipUndeployed leaves out files deployed as symlinks or templates
+// which have no work path yet. It warns about links which are not
+// intact and rendered templates which were edited, since commit reads
+// the work path and edits made elsewhere are not committed.
+func skipUndeployed(dotFiles []*file.DotFile) []*file.DotFile {
+	var kept []*file.DotFile
 	for _, dotFile := range dotFiles {
-		done, err := dotFile.AddCommit()
-		if err != nil {
-			return -1, errors.WithMessage(err, "failed to commit")
+		if !dotFile.IsSymlinked() && !dotFile.IsTemplate() {
+			kept = append(kept, dotFile)
+			continue
+		}
+		if exists, _ := file.Afs.Exists(dotFile.WorkPath()); !exists {
+			color.Yellow("Warning: skipped %s, run apply to deploy it first", dotFile.Path())
+			continue
 		}
-		if done {
-			fmt.Printf("Committed %s\n", dotFile.Path())
-			cnt += 1
+		state, err := deploy.Status(dotFile)
+		if err == nil {
+			switch state {
+			case deploy.Hijacked, deploy.Unlinked:
+				color.Yellow("Warning: %s is %s, committing %s", dotFile.Path(), state, dotFile.WorkPath())
+			case deploy.Edited:
+				color.Yellow("Warning: %s was edited, the edits belong in its template %s",
+					dotFile.Path(), dotFile.WorkPath())
+			}
 		}
+		kept = append(kept, dotFile)
 	}
-	return cnt, nil
+	return kept
 }
 
-func updateDotFiles(dotFiles []*file.DotFile) (int, error) {
-	cnt := 0
+// commitDotFiles commits or updates each file, and returns what
+// happened to the ones which changed.
+func commitDotFiles(dotFiles []*file.DotFile) ([]output.CommitResult, error) {
+	results := []output.CommitResult{}
 	for _, dotFile := range dotFiles {
-		done, err := dotFile.UpdateContent()
+		result, changed, err := reportCommit(dotFile, !noVerify)
 		if err != nil {
-			return -1, errors.WithMessage(err, "failed to commit")
+			return nil, err
+		}
+		if !changed {
+			continue
 		}
-		if done {
-			fmt.Printf("Updated %s\n", dotFile.Path())
-			cnt += 1
+		switch result.Result {
+		case output.Skipped:
+			color.Red("Skipped %s: %s", result.Path, result.Reason)
+		case output.Committed:
+			fmt.Fprintf(messages, "Committed %s\n", result.Path)
+		case output.Updated:
+			fmt.Fprintf(messages, "Updated %s\n", result.Path)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// dotFilesByArgs finds the dot-file named by each arg, which is
+// either an absolute path or a mnemonic.
+func dotFilesByArgs(dotFiles []*file.DotFile, args []string) ([]*file.DotFile, error) {
+	var found []*file.DotFile
+	for _, arg := range args {
+		var dotFile *file.DotFile
+		var err error
+		if strings.HasPrefix(arg, "/") {
+			dotFile, err = dotFileByPath(dotFiles, arg)
+		} else {
+			dotFile, err = dotFileByMnemonic(dotFiles, arg)
+		}
+		if err != nil {
+			return nil, err
 		}
+		found = append(found, dotFile)
 	}
-	return cnt, nil
+	return found, nil
 }
 
 func dotFileByPath(dotFiles []*file.DotFile, path string) (*file.DotFile, error) {
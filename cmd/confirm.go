@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// confirm asks the user to confirm the action described by prompt,
+// unless skip is set. Anything other than yes counts as no.
+func confirm(prompt string, skip bool) bool {
+	if skip {
+		return true
+	}
+	color.Yellow(prompt)
+	fmt.Fprint(messages, "Continue? [y/N] ")
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
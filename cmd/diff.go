@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/RedDocMD/dotted/diff"
+	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/output"
+	"github.com/RedDocMD/dotted/render"
+	"github.com/RedDocMD/dotted/store"
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [<path>|<mnemonic>]*",
+	Short: "show the uncommitted changes to dot-files",
+	Long: `Shows how every dot-file, or only the ones given, differs from its
+current version in the store, as a line diff. With --semantic, YAML,
+JSON and TOML files are compared by their keys and values instead,
+so reordering and reformatting are not changes, and templates are
+compared once rendered. Files which cannot be parsed fall back to the
+line diff.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dotFiles := fileStore.Files()
+		if len(args) != 0 {
+			var err error
+			dotFiles, err = dotFilesByArgs(dotFiles, args)
+			if err != nil {
+				return errors.WithMessage(err, "failed to diff")
+			}
+		}
+		startPager()
+		diffs := []output.FileDiff{}
+		for _, dotFile := range dotFiles {
+			working, err := file.Afs.ReadFile(dotFile.WorkPath())
+			if os.IsNotExist(err) {
+				color.Yellow("%s is missing, run apply to deploy it", dotFile.WorkPath())
+				continue
+			}
+			if err != nil {
+				return errors.Wrap(err, "failed to diff")
+			}
+			if string(working) == dotFile.Content() {
+				continue
+			}
+			fileDiff := output.FileDiff{Path: dotFile.Path()}
+			var changes []diff.Change
+			if semanticDiff {
+				changes, err = semanticChanges(dotFile, working)
+				if err != nil {
+					fileDiff.Fallback = err.Error()
+				} else {
+					fileDiff.Semantic = true
+				}
+			}
+			var hunks []diff.Hunk
+			if !fileDiff.Semantic {
+				hunks = diff.Hunks(diff.Lines(dotFile.Content(), string(working)), diffContext)
+			}
+			if output.IsStructured() {
+				diffs = append(diffs, fileDiffOf(fileDiff, changes, hunks))
+				continue
+			}
+			color.New(color.Bold).Printf("diff %s\n", dotFile.Path())
+			if len(fileDiff.Fallback) != 0 {
+				color.Yellow("cannot compare by keys: %s, showing a line diff", fileDiff.Fallback)
+			}
+			if fileDiff.Semantic {
+				printSemanticDiff(changes)
+			} else {
+				printTextDiff(hunks)
+			}
+		}
+		if output.IsStructured() {
+			return output.Print(diffs)
+		}
+		return nil
+	},
+}
+
+var semanticDiff bool
+
+func initDiffCommand() {
+	diffCmd.Flags().BoolVar(&semanticDiff, "semantic", false,
+		"compare YAML, JSON and TOML files by keys and values")
+}
+
+// diffContext is the number of equal lines shown around changes.
+const diffContext = 3
+
+func printTextDiff(hunks []diff.Hunk) {
+	for _, hunk := range hunks {
+		color.Cyan("%s", hunk.Header())
+		for _, line := range hunk.Lines {
+			switch line.Op {
+			case diff.Insert:
+				color.Green("%s", line)
+			case diff.Delete:
+				color.Red("%s", line)
+			default:
+				fmt.Fprintln(messages, line.String())
+			}
+		}
+	}
+}
+
+// semanticChanges compares the keys and values of dotFile with its
+// working copy, failing if either version cannot be parsed.
+func semanticChanges(dotFile *file.DotFile, working []byte) ([]diff.Change, error) {
+	stored := []byte(dotFile.Content())
+	if dotFile.IsTemplate() {
+		storedRendered, err := dotFile.Rendered()
+		if err != nil {
+			return nil, err
+		}
+		workingRendered, err := render.Render(filepath.Base(dotFile.WorkPath()), string(working))
+		if err != nil {
+			return nil, err
+		}
+		stored, working = []byte(storedRendered), []byte(workingRendered)
+	}
+	entry, _ := configs.EntryWithPath(dotFile.RelativePath(), store.Host())
+	return diff.Semantic(dotFile.Path(), entry.Format, stored, working)
+}
+
+func printSemanticDiff(changes []diff.Change) {
+	if len(changes) == 0 {
+		fmt.Fprintln(messages, "only the formatting changed")
+	}
+	for _, change := range changes {
+		switch change.Kind {
+		case diff.Added:
+			color.Green("+ %s", change)
+		case diff.Removed:
+			color.Red("- %s", change)
+		default:
+			color.Yellow("~ %s", change)
+		}
+	}
+}
+
+// fileDiffOf fills in the changes or hunks of fileDiff.
+func fileDiffOf(fileDiff output.FileDiff, changes []diff.Change, hunks []diff.Hunk) output.FileDiff {
+	if fileDiff.Semantic {
+		fileDiff.Changes = []output.KeyChange{}
+	}
+	for _, change := range changes {
+		fileDiff.Changes = append(fileDiff.Changes, output.KeyChange{
+			Path: change.Path,
+			Kind: change.Kind.String(),
+			Old:  change.Old,
+			New:  change.New,
+		})
+	}
+	for _, hunk := range hunks {
+		lines := make([]string, 0, len(hunk.Lines))
+		for _, line := range hunk.Lines {
+			lines = append(lines, line.String())
+		}
+		fileDiff.Hunks = append(fileDiff.Hunks, output.Hunk{Header: hunk.Header(), Lines: lines})
+	}
+	return fileDiff
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/git"
+	"github.com/RedDocMD/dotted/output"
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "export the history of files for use with other tools",
+}
+
+var exportGitCmd = &cobra.Command{
+	Use:   "git <dir> [<path>|<mnemonic>]*",
+	Short: "export the history of files as git repositories in a directory",
+	Long: `Exports the history of each file as a bare git repository in dir,
+with a branch for every tip of the history and HEAD at the current
+commit. Exporting again only adds the new commits. Encrypted files
+are skipped, as their history would be written in plain text, unless
+--include-encrypted is given.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return fmt.Errorf("expected a directory to export to")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := args[0]
+		var dotFiles []*file.DotFile
+		if len(args) == 1 {
+			dotFiles, _ = splitDotFilesByHistory(fileStore.Files())
+		} else {
+			var err error
+			dotFiles, err = dotFilesByArgs(fileStore.Files(), args[1:])
+			if err != nil {
+				return errors.WithMessage(err, "failed to export")
+			}
+		}
+		exports := []output.Export{}
+		for _, dotFile := range dotFiles {
+			repoDir := filepath.Join(dir, exportName(dotFile)+".git")
+			result, err := git.ExportHistory(dotFile, repoDir, exportEncrypted)
+			if errors.Is(err, git.EncryptedFile) {
+				color.Yellow("Skipped %s: encrypted, export it with --include-encrypted", dotFile.Path())
+				continue
+			} else if err != nil {
+				return err
+			}
+			if dotFile.IsEncrypted() {
+				color.Yellow("Warning: exported the history of %s in plain text", dotFile.Path())
+			}
+			fmt.Fprintf(messages, "Exported %s to %s: %d new commits, HEAD at %s\n",
+				dotFile.Path(), repoDir, result.Exported, result.Head)
+			exports = append(exports, output.Export{
+				Path:     dotFile.Path(),
+				Repo:     repoDir,
+				Exported: result.Exported,
+				Branches: result.Branches,
+				Head:     result.Head,
+			})
+		}
+		if output.IsStructured() {
+			return output.Print(exports)
+		}
+		color.Green("Exported %d files", len(exports))
+		return nil
+	},
+}
+
+var exportEncrypted bool
+
+func initExportCommand() {
+	exportGitCmd.Flags().BoolVar(&exportEncrypted, "include-encrypted", false,
+		"export encrypted files too, writing their history in plain text")
+	exportCmd.AddCommand(exportGitCmd)
+}
+
+// exportName names the repository a file is exported to.
+func exportName(dotFile *file.DotFile) string {
+	if len(dotFile.Mnemonic()) != 0 {
+		return dotFile.Mnemonic()
+	}
+	return strings.ReplaceAll(filepath.ToSlash(dotFile.RelativePath()), "/", "_")
+}
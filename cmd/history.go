@@ -2,30 +2,210 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
+	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/output"
+	"github.com/RedDocMD/dotted/printer"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
 var historyCmd = &cobra.Command{
 	Use:   "history <path|mnemonic>",
 	Short: "view the history of a file",
+	Long: `Lists the commits of a file as a tree, shows the file at a commit
+given by a tag or a prefix of its UUID, which defaults to the current
+commit, or drops the history. Commits are tagged with dtd ui. The
+frozen history of a file which moved to withoutHistory can be listed
+and viewed too.
+
+The commit must be joined to the flag, as in --view=1a2b or
+--view=stable, since in --view 1a2b the 1a2b is taken as a path or
+mnemonic.`,
 	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 2 && view == currentCommitArg {
+			return fmt.Errorf("expected exactly one path/mnemonic as arg, give the commit as --view=<commit|tag>")
+		}
 		if len(args) != 1 {
 			return fmt.Errorf("expected exactly one path/mnemonic as arg")
 		}
-		if list == view {
-			return fmt.Errorf("expected exactly one of list or view")
+		modes := 0
+		for _, mode := range []bool{list, cmd.Flags().Changed("view"), drop} {
+			if mode {
+				modes += 1
+			}
+		}
+		if modes != 1 {
+			return fmt.Errorf("expected exactly one of list, view or drop")
 		}
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return nil
+		dotFiles, err := dotFilesByArgs(fileStore.Files(), args)
+		if err != nil {
+			return err
+		}
+		dotFile := dotFiles[0]
+		if drop {
+			return dropHistory(dotFile)
+		}
+		root, current, err := historyOf(dotFile)
+		if err != nil {
+			return err
+		}
+		if list {
+			return listHistory(dotFile, root, current)
+		}
+		return viewHistory(dotFile, root, current, view)
 	},
 }
 
-var list, view bool
+var list, drop, dropYes bool
+var view string
+
+// currentCommitArg is what --view shows when it is given no commit.
+const currentCommitArg = "current"
 
 func initHistoryCommand() {
 	historyCmd.Flags().BoolVar(&list, "list", false, "list all commits of the file")
-	historyCmd.Flags().BoolVar(&view, "view", false, "view the file at a specified commit")
+	historyCmd.Flags().StringVar(&view, "view", "", "view the file at a commit or tag, given as --view=<commit|tag>, the current one if not given")
+	historyCmd.Flags().Lookup("view").NoOptDefVal = currentCommitArg
+	historyCmd.Flags().BoolVar(&drop, "drop", false,
+		"permanently delete the history of a file, or the frozen history of a file without history")
+	historyCmd.Flags().BoolVarP(&dropYes, "yes", "y", false, "do not ask for confirmation before dropping")
+}
+
+// historyOf returns the history of dotFile, or its frozen history if
+// it has none.
+func historyOf(dotFile *file.DotFile) (*file.HistoryNode, *file.HistoryNode, error) {
+	if dotFile.HasHistory() {
+		return dotFile.HistoryRoot(), dotFile.CurrentHistory(), nil
+	}
+	root := dotFile.FrozenHistory()
+	if root == nil {
+		return nil, nil, fmt.Errorf("%s has no history", dotFile.Path())
+	}
+	color.Yellow("%s has no history, showing its frozen history", dotFile.Path())
+	return root, dotFile.FrozenCurrent(), nil
+}
+
+func listHistory(dotFile *file.DotFile, root, current *file.HistoryNode) error {
+	if output.IsStructured() {
+		return output.Print(output.NewHistory(dotFile.Path(), root, current))
+	}
+	startPager()
+	return printer.TreeFprint(messages, historyTreeNode{root, current}, printer.TerminalOptions(os.Stdout))
+}
+
+// historyTreeNode prints a commit in the tree of history --list.
+type historyTreeNode struct {
+	node    *file.HistoryNode
+	current *file.HistoryNode
+}
+
+func (treeNode historyTreeNode) Render() string {
+	node := treeNode.node
+	line := fmt.Sprintf("%s %s", node.UUID(), node.Timestamp().Format("2006-01-02 15:04:05"))
+	if len(node.Message()) != 0 {
+		line += " " + strings.SplitN(node.Message(), "\n", 2)[0]
+	}
+	if node == treeNode.current {
+		line = color.GreenString("%s (current)", line)
+	}
+	if len(node.Tags()) != 0 {
+		line += color.YellowString(" [%s]", strings.Join(node.Tags(), ", "))
+	}
+	return line + "\n"
+}
+
+func (treeNode historyTreeNode) IsLeaf() bool {
+	return len(treeNode.node.Children()) == 0
+}
+
+func (treeNode historyTreeNode) Children() []printer.TreeNode {
+	var children []printer.TreeNode
+	for _, child := range treeNode.node.Children() {
+		children = append(children, historyTreeNode{child, treeNode.current})
+	}
+	return children
+}
+
+func viewHistory(dotFile *file.DotFile, root, current *file.HistoryNode, commit string) error {
+	node := current
+	if commit != currentCommitArg {
+		var err error
+		node, err = nodeWithPrefix(root, commit)
+		if err != nil {
+			return err
+		}
+	}
+	if output.IsStructured() {
+		return output.Print(output.Version{
+			Path:      dotFile.Path(),
+			Commit:    node.UUID(),
+			Timestamp: node.Timestamp(),
+			Content:   node.Content(),
+		})
+	}
+	startPager()
+	fmt.Fprint(messages, node.Content())
+	return nil
+}
+
+// nodeWithPrefix finds the commit tagged prefix, or else the one
+// whose UUID starts with prefix.
+func nodeWithPrefix(root *file.HistoryNode, prefix string) (*file.HistoryNode, error) {
+	if tagged := root.NodeWithTag(prefix); tagged != nil {
+		return tagged, nil
+	}
+	var found *file.HistoryNode
+	for _, node := range historyNodes(root) {
+		if !strings.HasPrefix(node.UUID(), prefix) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("commit %s is ambiguous", prefix)
+		}
+		found = node
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no commit %s", prefix)
+	}
+	return found, nil
+}
+
+// dropHistory deletes the history of dotFile after confirmation. A
+// file with history keeps only its current content as the root of a
+// new history.
+func dropHistory(dotFile *file.DotFile) error {
+	root := dotFile.FrozenHistory()
+	if dotFile.HasHistory() {
+		root = dotFile.HistoryRoot()
+	}
+	if root == nil {
+		color.Yellow("%s has no history to drop", dotFile.Path())
+		if output.IsStructured() {
+			return output.Print(output.Dropped{Path: dotFile.Path()})
+		}
+		return nil
+	}
+	versions := len(historyNodes(root))
+	prompt := fmt.Sprintf("This permanently deletes %d versions of %s.", versions, dotFile.Path())
+	if !confirm(prompt, dropYes) {
+		return fmt.Errorf("drop cancelled")
+	}
+	if dotFile.HasHistory() {
+		dotFile.RemoveHistory()
+		dotFile.DropFrozenHistory()
+		dotFile.InitHistory()
+	} else {
+		dotFile.DropFrozenHistory()
+	}
+	color.Green("Dropped the history of %s", dotFile.Path())
+	if output.IsStructured() {
+		return output.Print(output.Dropped{Path: dotFile.Path(), Versions: versions})
+	}
+	return nil
 }
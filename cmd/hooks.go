@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/RedDocMD/dotted/deploy"
+	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/hook"
+	"github.com/RedDocMD/dotted/output"
+	"github.com/RedDocMD/dotted/render"
+	"github.com/RedDocMD/dotted/store"
+	"github.com/RedDocMD/dotted/validate"
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+)
+
+// stoppedError is returned when a check, a validator or a pre-hook,
+// stops a file from being committed or applied. Other files go ahead.
+type stoppedError struct {
+	err error
+}
+
+func (e *stoppedError) Error() string {
+	return e.err.Error()
+}
+
+func isStopped(err error) bool {
+	var stoppedErr *stoppedError
+	return errors.As(err, &stoppedErr)
+}
+
+// hookEnv describes dotFile to the hooks of a pre-event, which run
+// before there is a new commit. NewCommit is set for the post-event.
+func hookEnv(event string, dotFile *file.DotFile) hook.Env {
+	return hook.Env{
+		Event:     event,
+		Path:      dotFile.Path(),
+		Source:    dotFile.WorkPath(),
+		Mnemonic:  dotFile.Mnemonic(),
+		OldCommit: currentCommit(dotFile),
+	}
+}
+
+func currentCommit(dotFile *file.DotFile) string {
+	if !dotFile.HasHistory() {
+		return ""
+	}
+	return dotFile.CurrentHistory().UUID()
+}
+
+// runPostHook runs the post-hooks of an event, which only warn when
+// they fail since the file was already changed.
+func runPostHook(commands []string, env hook.Env) {
+	if err := hook.Run(commands, env); err != nil {
+		color.Yellow("Warning: %v", err)
+	}
+}
+
+// commitDotFile commits a file with history, or updates the content
+// of a file without, and returns whether it changed. Unless verify is
+// off, a changed file must pass its validator and preCommit hooks or
+// it is left as it is and a stoppedError is returned. The postCommit
+// hooks run after every commit.
+func commitDotFile(dotFile *file.DotFile, verify bool) (bool, error) {
+	modified, err := dotFile.IsModified()
+	if err != nil || !modified {
+		return false, errors.WithMessage(err, "failed to commit")
+	}
+	if verify {
+		if err := validateDotFile(dotFile); err != nil {
+			return false, &stoppedError{err}
+		}
+	}
+	hooks := configs.HooksFor(dotFile.RelativePath(), store.Host())
+	env := hookEnv(hook.PreCommit, dotFile)
+	if verify {
+		if err := hook.Run(hooks.PreCommit, env); err != nil {
+			return false, &stoppedError{err}
+		}
+	}
+	var done bool
+	if dotFile.HasHistory() {
+		done, err = dotFile.AddCommit()
+	} else {
+		done, err = dotFile.UpdateContent()
+	}
+	if err != nil || !done {
+		return done, errors.WithMessage(err, "failed to commit")
+	}
+	env.Event = hook.PostCommit
+	env.NewCommit = currentCommit(dotFile)
+	runPostHook(hooks.PostCommit, env)
+	return true, nil
+}
+
+// reportCommit commits dotFile like commitDotFile and describes what
+// happened, returning false if the file did not change.
+func reportCommit(dotFile *file.DotFile, verify bool) (output.CommitResult, bool, error) {
+	result := output.CommitResult{Path: dotFile.Path(), OldCommit: currentCommit(dotFile)}
+	done, err := commitDotFile(dotFile, verify)
+	if isStopped(err) {
+		result.Result = output.Skipped
+		result.Reason = err.Error()
+		return result, true, nil
+	}
+	if err != nil || !done {
+		return result, false, err
+	}
+	result.NewCommit = currentCommit(dotFile)
+	if dotFile.HasHistory() {
+		result.Result = output.Committed
+	} else {
+		result.Result = output.Updated
+	}
+	return result, true, nil
+}
+
+// validateDotFile checks the syntax of the content at the work path of
+// dotFile, by the format of its entry or the extension of its path.
+// Templates are checked once rendered.
+func validateDotFile(dotFile *file.DotFile) error {
+	entry, _ := configs.EntryWithPath(dotFile.RelativePath(), store.Host())
+	if validate.FormatOf(dotFile.Path(), entry.Format) == "" {
+		return nil
+	}
+	content, err := file.Afs.ReadFile(dotFile.WorkPath())
+	if err != nil {
+		return errors.Wrap(err, "failed to validate")
+	}
+	if dotFile.IsTemplate() {
+		rendered, err := render.Render(filepath.Base(dotFile.WorkPath()), string(content))
+		if err != nil {
+			return err
+		}
+		content = []byte(rendered)
+	}
+	return validate.Validate(dotFile.Path(), entry.Format, content)
+}
+
+// applyDotFile deploys a file like deploy.Apply, running the apply
+// hooks when the file is written. A failing preApply hook leaves the
+// file as it is and returns a stoppedError, see isStopped.
+func applyDotFile(dotFile *file.DotFile, dryRun bool) (deploy.Change, error) {
+	change, err := deploy.Apply(dotFile, true)
+	if err != nil || dryRun || change.Action == deploy.Unchanged {
+		return change, err
+	}
+	hooks := configs.HooksFor(dotFile.RelativePath(), store.Host())
+	env := hookEnv(hook.PreApply, dotFile)
+	if err := hook.Run(hooks.PreApply, env); err != nil {
+		return change, &stoppedError{err}
+	}
+	change, err = deploy.Apply(dotFile, false)
+	if err != nil {
+		return change, err
+	}
+	env.Event = hook.PostApply
+	env.NewCommit = currentCommit(dotFile)
+	runPostHook(hooks.PostApply, env)
+	return change, nil
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+
+	"github.com/RedDocMD/dotted/config"
+	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/fs"
+	"github.com/RedDocMD/dotted/hook"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommitHookEnv(t *testing.T) {
+	if _, err := exec.LookPath(hook.Shell); err != nil {
+		t.Skip("no shell installed")
+	}
+	var out bytes.Buffer
+	stdout := hook.Stdout
+	hook.Stdout = &out
+	file.Fs, file.Afs = fs.MockFs, fs.MockAfs
+	defer func() {
+		hook.Stdout = stdout
+		file.Fs, file.Afs = fs.OsFs, fs.OsAfs
+		configs = nil
+		fs.MockFs.RemoveAll("/")
+	}()
+
+	path := "/home/dknite/.tmux.conf"
+	fs.MockAfs.MkdirAll("/home/dknite", 0755)
+	fs.MockAfs.WriteFile(path, []byte("set -g mouse on\n"), 0644)
+	dotFile, err := file.NewDotFile(path, "tmux", true)
+	assert.Nil(t, err)
+	oldCommit := dotFile.CurrentHistory().UUID()
+	configs = &config.Config{
+		WithHistory: []config.FileEntry{{Path: ".tmux.conf", Mnemonic: "tmux"}},
+		Hooks: config.Hooks{
+			PreCommit:  config.StringList{`echo "$DOTTED_EVENT $DOTTED_OLD_COMMIT -> $DOTTED_NEW_COMMIT"`},
+			PostCommit: config.StringList{`echo "$DOTTED_EVENT $DOTTED_OLD_COMMIT -> $DOTTED_NEW_COMMIT"`},
+		},
+	}
+
+	fs.MockAfs.WriteFile(path, []byte("set -g mouse off\n"), 0644)
+	done, err := commitDotFile(dotFile, true)
+	assert.Nil(t, err)
+	assert.True(t, done)
+	newCommit := dotFile.CurrentHistory().UUID()
+	assert.NotEqual(t, oldCommit, newCommit)
+	assert.Equal(t, "preCommit "+oldCommit+" -> \n"+
+		"postCommit "+oldCommit+" -> "+newCommit+"\n", out.String())
+
+	// A failing preCommit hook stops the commit
+	out.Reset()
+	configs.Hooks.PreCommit = config.StringList{"exit 1"}
+	fs.MockAfs.WriteFile(path, []byte("set -g mouse on\n"), 0644)
+	done, err = commitDotFile(dotFile, true)
+	assert.True(t, isStopped(err))
+	assert.False(t, done)
+	assert.Equal(t, newCommit, dotFile.CurrentHistory().UUID())
+	assert.Empty(t, out.String())
+}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/RedDocMD/dotted/config"
+	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/git"
+	"github.com/RedDocMD/dotted/importer"
+	"github.com/RedDocMD/dotted/output"
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "import files and their history from other tools",
+}
+
+var importGitCmd = &cobra.Command{
+	Use:   "git <repo> --path <path>",
+	Short: "import the history of a file from a git repository",
+	Long: `Imports every commit of a git repository which changed the file at
+path as the history of that file, keeping commit times and messages.
+The repository may be a bare repository with $HOME as its work tree,
+in which case path is also the path of the file relative to $HOME.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("expected exactly one repository as arg")
+		}
+		if len(importPath) == 0 {
+			return fmt.Errorf("expected a path to import")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, tip, err := git.ImportHistory(args[0], filepath.ToSlash(importPath))
+		if err != nil {
+			return err
+		}
+		dotFile, err := attachHistory(filepath.FromSlash(importPath), importMnemonic, root, tip)
+		if err != nil {
+			return err
+		}
+		commits := len(historyNodes(root))
+		color.Green("Imported %d commits of %s", commits, dotFile.Path())
+		if output.IsStructured() {
+			return output.Print(output.Import{
+				Files:   []output.File{output.NewFile(dotFile, configs.StoreLocation)},
+				Commits: commits,
+				Skipped: []string{},
+			})
+		}
+		return nil
+	},
+}
+
+var importStowCmd = &cobra.Command{
+	Use:   "stow <dir>",
+	Short: "import the packages of a GNU Stow directory",
+	Long: `Adds every file in the packages of a stow directory to the config
+and the store, as if the packages were stowed into $HOME. Names
+starting with dot- are decoded as with stow --dotfiles.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return importLayout(importer.Stow, args[0])
+	},
+}
+
+var importChezmoiCmd = &cobra.Command{
+	Use:   "chezmoi [dir]",
+	Short: "import a chezmoi source directory",
+	Long: `Adds every file in a chezmoi source directory, which defaults to
+~/.local/share/chezmoi, to the config and the store. Templates,
+scripts and files encrypted by chezmoi are skipped.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := file.Fs.Join(file.Fs.UserHomeDir(), ".local/share/chezmoi")
+		if len(args) == 1 {
+			dir = args[0]
+		}
+		return importLayout(importer.Chezmoi, dir)
+	},
+}
+
+var importYadmCmd = &cobra.Command{
+	Use:   "yadm [repo]",
+	Short: "import the files tracked by yadm",
+	Long: `Adds every file committed to a yadm repository, which defaults to
+~/.local/share/yadm/repo.git, to the config and the store with its
+content at HEAD. Alternate files are skipped.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repo := ""
+		if len(args) == 1 {
+			repo = args[0]
+		}
+		return importLayout(importer.Yadm, repo)
+	},
+}
+
+var importPath, importMnemonic string
+var importNoHistory, importDryRun bool
+
+func initImportCommand() {
+	importCmd.AddCommand(importGitCmd)
+	importCmd.AddCommand(importStowCmd)
+	importCmd.AddCommand(importChezmoiCmd)
+	importCmd.AddCommand(importYadmCmd)
+	for _, layoutCmd := range []*cobra.Command{importStowCmd, importChezmoiCmd, importYadmCmd} {
+		layoutCmd.Flags().BoolVar(&importNoHistory, "no-history", false,
+			"add the files to withoutHistory instead of withHistory")
+		layoutCmd.Flags().BoolVar(&importDryRun, "dry-run", false,
+			"print the config entries without adding them")
+	}
+	importGitCmd.Flags().StringVar(&importPath, "path", "",
+		"path of the file in the repository, relative to $HOME")
+	importGitCmd.Flags().StringVar(&importMnemonic, "mnemonic", "",
+		"mnemonic for the file if it is not in the config yet")
+}
+
+// attachHistory makes the history rooted at root the history of the
+// file at relPath, adding it to the config if it is not there yet.
+// A file already in the config moves to withHistory with its entry
+// as it is, but a file which already has a history is refused.
+func attachHistory(relPath, mnemonic string, root, tip *file.HistoryNode) (*file.DotFile, error) {
+	path := file.Fs.Join(file.Fs.UserHomeDir(), relPath)
+	existing, _ := dotFileByPath(fileStore.Files(), path)
+	if existing != nil {
+		if existing.HasHistory() {
+			return nil, fmt.Errorf("failed to import: %s already has a history", path)
+		}
+		mnemonic = existing.Mnemonic()
+	}
+	dotFile, err := file.NewDotFileFromHistory(path, mnemonic, root, tip)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to import")
+	}
+	if existing != nil {
+		dotFile.SetEncrypted(existing.IsEncrypted())
+		if existing.IsTemplate() {
+			dotFile.SetTemplate(existing.WorkPath())
+		} else if existing.IsSymlinked() {
+			dotFile.SetWorkPath(existing.WorkPath())
+		}
+		if _, err = config.MoveEntry(configPath, filepath.ToSlash(relPath), true); err != nil {
+			return nil, errors.WithMessage(err, "failed to import")
+		}
+		fileStore.RemoveFile(existing)
+	} else {
+		entry := config.FileEntry{Path: filepath.ToSlash(relPath), Mnemonic: mnemonic}
+		if err = config.AddEntry(configPath, entry, true); err != nil {
+			return nil, errors.WithMessage(err, "failed to import")
+		}
+	}
+	if err = fileStore.AddFile(dotFile); err != nil {
+		return nil, errors.WithMessage(err, "failed to import")
+	}
+	return dotFile, nil
+}
+
+// importLayout adds the files found by find in source to the config
+// and the store, skipping the ones already tracked.
+func importLayout(find func(string, map[string]struct{}) (importer.Result, error), source string) error {
+	taken := make(map[string]struct{})
+	for _, dotFile := range fileStore.Files() {
+		taken[dotFile.Mnemonic()] = struct{}{}
+	}
+	result, err := find(source, taken)
+	if err != nil {
+		return err
+	}
+	imported := output.Import{Files: []output.File{}, Skipped: []string{}}
+	for _, skipped := range result.Skipped {
+		color.Yellow("Skipped %s", skipped)
+		imported.Skipped = append(imported.Skipped, skipped)
+	}
+	for _, candidate := range result.Candidates {
+		path := file.Fs.Join(file.Fs.UserHomeDir(), filepath.FromSlash(candidate.Entry.Path))
+		if _, err := dotFileByPath(fileStore.Files(), path); err == nil {
+			color.Yellow("Skipped %s: already tracked", path)
+			imported.Skipped = append(imported.Skipped, path+": already tracked")
+			continue
+		}
+		if importDryRun {
+			fmt.Fprintf(messages, "- path: %s\n  mnemonic: %s\n", candidate.Entry.Path, candidate.Entry.Mnemonic)
+			imported.Entries = append(imported.Entries, output.Entry{
+				Path:     candidate.Entry.Path,
+				Mnemonic: candidate.Entry.Mnemonic,
+			})
+			continue
+		}
+		dotFile, err := file.NewDotFileWithContent(path, candidate.Entry.Mnemonic, candidate.Content, !importNoHistory)
+		if err != nil {
+			return errors.WithMessage(err, "failed to import")
+		}
+		if err = config.AddEntry(configPath, candidate.Entry, !importNoHistory); err != nil {
+			return errors.WithMessage(err, "failed to import")
+		}
+		if err = fileStore.AddFile(dotFile); err != nil {
+			return errors.WithMessage(err, "failed to import")
+		}
+		imported.Files = append(imported.Files, output.NewFile(dotFile, configs.StoreLocation))
+	}
+	if !importDryRun {
+		color.Green("Imported %d files", len(imported.Files))
+	}
+	if output.IsStructured() {
+		return output.Print(imported)
+	}
+	return nil
+}
+
+func historyNodes(node *file.HistoryNode) []*file.HistoryNode {
+	nodes := []*file.HistoryNode{node}
+	for _, child := range node.Children() {
+		nodes = append(nodes, historyNodes(child)...)
+	}
+	return nodes
+}
@@ -5,19 +5,91 @@ import (
 	"os"
 
 	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/output"
 	"github.com/RedDocMD/dotted/printer"
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "lists all the dot-files in the store",
-	Run: func(cmd *cobra.Command, args []string) {
-		var table printer.TablePrinter = FileTable(fileStore.Files())
-		printer.TablePrint(table)
+	Long: `Lists the dot-files in the store. The columns path, mnemonic and
+history can be sorted by with --sort, and filtered with --filter
+column=value, where paths and mnemonics match value as a glob and
+history is true or false. With --format, the table is printed as
+CSV, TSV or Markdown instead of with borders.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dotFiles := fileStore.Files()
+		if listArchived {
+			dotFiles = nil
+			for _, path := range fileStore.Archived() {
+				dotFile, err := fileStore.LoadArchived(path)
+				if err != nil {
+					return err
+				}
+				dotFiles = append(dotFiles, dotFile)
+			}
+		}
+		dotFiles, err := selectDotFiles(FileTable(dotFiles))
+		if err != nil {
+			return errors.WithMessage(err, "failed to list")
+		}
+		if output.IsStructured() {
+			if cmd.Flags().Changed("format") {
+				color.Yellow("Warning: --format is ignored with --output %s", output.Format)
+			}
+			files := output.NewFiles(dotFiles, configs.StoreLocation)
+			for i := range files {
+				files[i].Archived = listArchived
+			}
+			return output.Print(files)
+		}
+		startPager()
+		var table printer.TablePrinter = FileTable(dotFiles)
+		return printer.Fprint(messages, table, listFormat, printer.TerminalOptions(os.Stdout))
 	},
 }
 
+var listArchived, listReverse bool
+var listSort, listFormat string
+var listFilters []string
+
+func initListCommand() {
+	listCmd.Flags().BoolVar(&listArchived, "archived", false,
+		"list the dot-files archived after leaving the config instead")
+	listCmd.Flags().StringVar(&listSort, "sort", "",
+		"sort by the column path, mnemonic or history")
+	listCmd.Flags().BoolVar(&listReverse, "reverse", false, "sort in descending order")
+	listCmd.Flags().StringArrayVar(&listFilters, "filter", nil,
+		"only list files whose column matches, as column=value")
+	listCmd.Flags().StringVar(&listFormat, "format", printer.BoxFormat,
+		"print the table as table, csv, tsv or markdown")
+}
+
+// selectDotFiles filters and sorts the files of table by the flags of
+// list.
+func selectDotFiles(table FileTable) ([]*file.DotFile, error) {
+	var filters []printer.Filter
+	for _, arg := range listFilters {
+		filter, err := printer.ParseFilter(arg)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	rows, err := printer.Select(table, filters, listSort, listReverse)
+	if err != nil {
+		return nil, err
+	}
+	var selected []*file.DotFile
+	for _, row := range rows {
+		selected = append(selected, table[row-1])
+	}
+	return selected, nil
+}
+
 type FileTable []*file.DotFile
 
 func (table FileTable) RowCount() int {
@@ -53,6 +125,22 @@ func (table FileTable) Value(row, column int) string {
 	return ""
 }
 
+func (table FileTable) ColumnName(column int) string {
+	return [3]string{"path", "mnemonic", "history"}[column]
+}
+
+func (table FileTable) TypedValue(row, column int) interface{} {
+	file := table[row-1]
+	switch column {
+	case 0:
+		return file.Path()
+	case 1:
+		return file.Mnemonic()
+	default:
+		return file.HasHistory()
+	}
+}
+
 func (table FileTable) Ipad() int {
 	return 1
 }
@@ -0,0 +1,48 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+// outputHelpCmd documents --output, and is only a help topic.
+var outputHelpCmd = &cobra.Command{
+	Use:   "output",
+	Short: "the structures printed with --output json or yaml",
+	Long: `With --output json or --output yaml, commands print a single structure
+to stdout, and their messages and warnings go to stderr. Fields are
+only ever added to these structures, never renamed or removed.
+
+A file is
+  {path, relativePath, mnemonic, hasHistory, encrypted, deploy,
+   workPath, currentCommit, storePath, archived}
+where deploy is copy, symlink or template, and currentCommit is left
+out for files without history.
+
+  list            a list of files
+  add             the file added
+  purge           a list of the paths purged
+  status          a list of {path, deploy, state}
+  history --list  {path, currentCommit, commits}, commits being
+                  {uuid, parent, children, timestamp, message, tags,
+                  current}
+                  with every commit after its parent
+  history --view  {path, commit, timestamp, content}
+  history --drop  {path, versions}, versions being 0 if there was no
+                  history to drop
+  commit          a list of {path, result, oldCommit, newCommit, reason}
+                  for each changed file, result being committed, updated
+                  or skipped
+  watch           the same as commit for every file it commits, with its
+                  time, one JSON line or YAML document each
+  apply           a list of {path, action, backups, reason}, action being
+                  create, replace, unchanged or skipped
+  diff            a list of {path, semantic, fallback, changes, hunks},
+                  changes being {path, kind, old, new} and hunks being
+                  {header, lines}
+  backup          {target, transferred, skipped, unchanged, encrypted}
+  sync            {target, files, skipped, conflicts}, files being
+                  {path, added, behind, diverged, currentCommit,
+                  remoteTip, tagClashes}, tagClashes being {tag, kept,
+                  dropped}, and conflicts being {path, reason}
+  export git      a list of {path, repo, exported, branches, head}
+  import          {files, commits, skipped, entries}, entries being the
+                  {path, mnemonic} config entries of a --dry-run`,
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/RedDocMD/dotted/output"
+	"github.com/RedDocMD/dotted/plugin"
+	"github.com/spf13/pflag"
+)
+
+// findPlugin returns the plugin and its args if args start with a
+// subcommand which dtd does not have, but a dtd-<name> executable on
+// $PATH provides, as git does for git-<name>. The persistent flags of
+// the root may come before the subcommand, and are parsed.
+func findPlugin(args []string) (string, []string, bool) {
+	name := rootFlagsEnd(args)
+	if name == len(args) || strings.HasPrefix(args[name], "-") {
+		return "", nil, false
+	}
+	if cmd, _, _ := rootCmd.Find(args[name:]); cmd != rootCmd {
+		return "", nil, false
+	}
+	path, ok := plugin.Find(args[name])
+	if !ok || rootCmd.PersistentFlags().Parse(args[:name]) != nil {
+		// cobra reports the unknown command or the bad flag
+		return "", nil, false
+	}
+	return path, args[name+1:], true
+}
+
+// rootFlagsEnd returns the index of the first arg after the persistent
+// flags of the root, and their values, which start args.
+func rootFlagsEnd(args []string) int {
+	flags := rootCmd.PersistentFlags()
+	for i := 0; i < len(args); {
+		arg := args[i]
+		if arg == "-" || arg == "--" || !strings.HasPrefix(arg, "-") {
+			return i
+		}
+		var flag *pflag.Flag
+		var hasValue bool
+		if strings.HasPrefix(arg, "--") {
+			name := arg[2:]
+			if eq := strings.Index(name, "="); eq >= 0 {
+				name, hasValue = name[:eq], true
+			}
+			flag = flags.Lookup(name)
+		} else {
+			flag = flags.ShorthandLookup(arg[1:2])
+			hasValue = len(arg) > 2
+		}
+		if flag == nil {
+			return i
+		}
+		i += 1
+		if !hasValue && flag.NoOptDefVal == "" {
+			i += 1
+		}
+	}
+	return len(args)
+}
+
+// runPlugin runs the plugin at path with the config and the store
+// loaded, and returns its exit code. The store is not saved after, as
+// the plugin may have changed it.
+func runPlugin(path string, args []string) int {
+	initOutput()
+	initConfigAndStore()
+	code, err := plugin.Run(path, args, plugin.Env{
+		Output:        outputFormat,
+		ConfigPath:    configPath,
+		StoreLocation: configs.StoreLocation,
+		Files:         output.NewFiles(fileStore.Files(), configs.StoreLocation),
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return code
+}
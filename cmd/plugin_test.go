@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/RedDocMD/dotted/output"
+	"github.com/RedDocMD/dotted/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRootFlagsEnd(t *testing.T) {
+	assert.Equal(t, 0, rootFlagsEnd([]string{"foo", "-o", "json"}))
+	assert.Equal(t, 2, rootFlagsEnd([]string{"-o", "json", "foo"}))
+	assert.Equal(t, 1, rootFlagsEnd([]string{"-ojson", "foo"}))
+	assert.Equal(t, 2, rootFlagsEnd([]string{"--output", "json", "foo"}))
+	assert.Equal(t, 1, rootFlagsEnd([]string{"--output=json", "foo"}))
+	assert.Equal(t, 1, rootFlagsEnd([]string{"--no-pager", "foo"}))
+	assert.Equal(t, 3, rootFlagsEnd([]string{"--no-pager", "-o", "yaml", "foo", "--no-pager"}))
+	assert.Equal(t, 1, rootFlagsEnd([]string{"--no-pager", "--unknown", "foo"}))
+	assert.Equal(t, 0, rootFlagsEnd([]string{"--", "foo"}))
+	assert.Equal(t, 2, rootFlagsEnd([]string{"-o", "json"}))
+}
+
+func TestFindPluginAfterRootFlags(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, plugin.Prefix+"echo")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	defer func() {
+		outputFormat = output.Table
+		noPager = false
+	}()
+
+	path, args, ok := findPlugin([]string{"-o", "json", "--no-pager", "echo", "-o", "yaml"})
+	assert.True(t, ok)
+	assert.Equal(t, script, path)
+	assert.Equal(t, []string{"-o", "yaml"}, args)
+	assert.Equal(t, "json", outputFormat)
+	assert.True(t, noPager)
+
+	_, _, ok = findPlugin([]string{"--no-pager", "missing"})
+	assert.False(t, ok)
+	_, _, ok = findPlugin([]string{"-o", "json", "list"})
+	assert.False(t, ok)
+}
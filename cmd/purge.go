@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/output"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var purgeCmd = &cobra.Command{
+	Use:   "purge <path>+",
+	Short: "permanently delete archived dot-files from the store",
+	Long: `Deletes the store data, including the whole history, of archived
+dot-files, given by their paths as shown by list --archived or
+relative to $HOME.
+This cannot be undone.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return fmt.Errorf("expected at least one archived path")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prompt := fmt.Sprintf("This permanently deletes %d archived files with their history.", len(args))
+		if !confirm(prompt, purgeYes) {
+			return fmt.Errorf("purge cancelled")
+		}
+		purged := []string{}
+		for _, path := range args {
+			if filepath.IsAbs(path) {
+				relPath, err := filepath.Rel(file.Fs.UserHomeDir(), path)
+				if err != nil {
+					return fmt.Errorf("failed to purge %s: not under $HOME", path)
+				}
+				path = filepath.ToSlash(relPath)
+			}
+			if err := fileStore.Purge(path); err != nil {
+				return err
+			}
+			color.Green("Purged %s", path)
+			purged = append(purged, path)
+		}
+		if output.IsStructured() {
+			return output.Print(purged)
+		}
+		return nil
+	},
+}
+
+var purgeYes bool
+
+func initPurgeCommand() {
+	purgeCmd.Flags().BoolVarP(&purgeYes, "yes", "y", false, "do not ask for confirmation")
+}
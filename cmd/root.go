@@ -2,13 +2,23 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
 	"github.com/RedDocMD/dotted/config"
+	"github.com/RedDocMD/dotted/crypt"
+	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/hook"
+	"github.com/RedDocMD/dotted/output"
+	"github.com/RedDocMD/dotted/pager"
+	"github.com/RedDocMD/dotted/render"
 	"github.com/RedDocMD/dotted/store"
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"golang.org/x/term"
 )
 
 var rootCmd = &cobra.Command{
@@ -18,14 +28,27 @@ var rootCmd = &cobra.Command{
 gives full version control of individual files 
 (along with implicit branching).
 Supports multiple backup and restore options.
+Runs dtd-<name> on $PATH for a subcommand it does not have.
 ★ Inspired by Git. Guided by stars. ★`,
 }
 
 var configs *config.Config
+var configPath string
 var fileStore *store.Store
+var outputFormat string
+var noPager bool
+
+// messages is where commands print what they do. It is stderr when
+// the output is structured, so that stdout only has the structure.
+var messages io.Writer = os.Stdout
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	if path, args, ok := findPlugin(os.Args[1:]); ok {
+		os.Exit(runPlugin(path, args))
+	}
+	err := rootCmd.Execute()
+	stopPager()
+	if err != nil {
 		os.Exit(1)
 	}
 	if fileStore != nil {
@@ -37,14 +60,78 @@ func Execute() {
 }
 
 func init() {
-	cobra.OnInitialize(initConfigAndStore)
+	cobra.OnInitialize(initOutput, initConfigAndStore)
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", output.Table,
+		"print table, json or yaml, see dtd help output")
+	rootCmd.PersistentFlags().BoolVar(&noPager, "no-pager", false,
+		"do not page long output, which goes through $PAGER or less -R in a terminal")
+	rootCmd.AddCommand(outputHelpCmd)
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(commitCommand)
 	initCommitCommand()
 	rootCmd.AddCommand(historyCmd)
 	initHistoryCommand()
+	rootCmd.AddCommand(backupCmd)
+	initBackupCommand()
+	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(exportCmd)
+	initExportCommand()
+	rootCmd.AddCommand(importCmd)
+	initImportCommand()
+	rootCmd.AddCommand(applyCmd)
+	initApplyCommand()
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(addCmd)
+	initAddCommand()
+	rootCmd.AddCommand(purgeCmd)
+	initPurgeCommand()
+	initListCommand()
+	rootCmd.AddCommand(watchCmd)
+	initWatchCommand()
+	rootCmd.AddCommand(diffCmd)
+	initDiffCommand()
+	rootCmd.AddCommand(uiCmd)
+}
+
+func initOutput() {
+	if err := output.SetFormat(outputFormat); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if output.IsStructured() {
+		messages = os.Stderr
+		color.Output = os.Stderr
+		hook.Stdout = os.Stderr
+	}
+}
+
+// startPager sends what the command prints through the pager, if it
+// prints to a terminal without structured output. Commands with long
+// output call it.
+func startPager() {
+	if noPager || output.IsStructured() || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return
+	}
+	command := pager.Command(configs.Pager)
+	if len(command) == 0 {
+		return
+	}
+	// Colours stay on, as fatih/color checks stdout is a terminal
+	pagerWriter := pager.New(command, os.Stdout)
+	previousMessages, previousColor := messages, color.Output
+	messages, color.Output = pagerWriter, pagerWriter
+	stopPager = func() {
+		if err := pagerWriter.Close(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		messages, color.Output = previousMessages, previousColor
+		stopPager = func() {}
+	}
 }
 
+// stopPager waits for the user to quit the pager, if it started.
+var stopPager = func() {}
+
 func initConfigAndStore() {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -62,16 +149,45 @@ func initConfigAndStore() {
 		fmt.Fprintln(os.Stderr, "failed to find config file")
 		os.Exit(1)
 	}
-	path := viper.GetViper().ConfigFileUsed()
-	configs, err = config.ReadConfig(path)
+	configPath = viper.GetViper().ConfigFileUsed()
+	configs, err = config.ReadConfig(configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	file.Encryption, err = loadCipher(configs)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+	render.Variables = configs.Variables
 
 	fileStore, err = store.LoadFromDisk(configs)
+	if errors.Is(err, file.KeyNotFound) {
+		fmt.Fprintf(os.Stderr, "%v\nset keyFile in the config or %s in the environment\n",
+			err, crypt.PassphraseVariable)
+		os.Exit(1)
+	}
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+	for _, path := range fileStore.NewlyArchived() {
+		color.Yellow("Archived %s as it is no longer in the config, restore it with dtd add", path)
+	}
+	for _, path := range fileStore.NewlyFrozen() {
+		color.Yellow("Froze the history of %s as it moved to withoutHistory, "+
+			"it is restored when moving back and deleted with dtd history --drop", path)
+	}
+}
+
+func loadCipher(configs *config.Config) (crypt.Cipher, error) {
+	if len(configs.KeyFile) != 0 {
+		return crypt.NewKeyFileCipher(configs.KeyFile)
+	}
+	if passphrase, ok := os.LookupEnv(crypt.PassphraseVariable); ok {
+		return crypt.NewPassphraseCipher(passphrase)
+	}
+	return nil, nil
 }
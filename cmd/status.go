@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/RedDocMD/dotted/config"
+	"github.com/RedDocMD/dotted/deploy"
+	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/output"
+	"github.com/RedDocMD/dotted/printer"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "show how the dot-files on disk compare to the store",
+	Long: `Shows whether each dot-file on disk is the current version in the
+store. Files deployed as symlinks are also checked for broken links,
+links pointing outside the work tree of the store (hijacked) and
+regular files in place of the link (unlinked). Templates are rendered
+and checked for edits made to the output instead of the template
+(edited).`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		table := StatusTable{}
+		for _, dotFile := range fileStore.Files() {
+			state, err := deploy.Status(dotFile)
+			if err != nil {
+				return err
+			}
+			table = append(table, fileStatus{dotFile, state})
+		}
+		if output.IsStructured() {
+			statuses := make([]output.Status, 0, len(table))
+			for _, status := range table {
+				statuses = append(statuses, output.Status{
+					Path:   status.dotFile.Path(),
+					Deploy: status.deployMode(),
+					State:  status.state.String(),
+				})
+			}
+			return output.Print(statuses)
+		}
+		return printer.TableFprint(messages, table, printer.TerminalOptions(os.Stdout))
+	},
+}
+
+type fileStatus struct {
+	dotFile *file.DotFile
+	state   deploy.State
+}
+
+func (status fileStatus) deployMode() string {
+	if status.dotFile.IsTemplate() {
+		return output.TemplateDeploy
+	}
+	if status.dotFile.IsSymlinked() {
+		return config.SymlinkDeploy
+	}
+	return config.CopyDeploy
+}
+
+type StatusTable []fileStatus
+
+func (table StatusTable) RowCount() int {
+	return len(table) + 1
+}
+
+func (table StatusTable) ColumnCount() int {
+	return 3
+}
+
+func (table StatusTable) Value(row, column int) string {
+	var columnHeaders = [3]string{"Path", "Deploy", "State"}
+	if row == 0 {
+		return columnHeaders[column]
+	}
+	status := table[row-1]
+	switch column {
+	case 0:
+		return status.dotFile.Path()
+	case 1:
+		return status.deployMode()
+	case 2:
+		return status.state.String()
+	}
+	// Should not reach the following
+	fmt.Fprintln(os.Stderr, "invalid column while printing status")
+	os.Exit(1)
+	return ""
+}
+
+func (table StatusTable) Ipad() int {
+	return 1
+}
+
+func (table StatusTable) ColumnAlignment(column int) printer.ColumnAlignment {
+	return printer.LeftAlign
+}
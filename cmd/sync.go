@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/RedDocMD/dotted/backup"
+	"github.com/RedDocMD/dotted/output"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync <target>",
+	Short: "merge the history of every file with its copy on a backup target",
+	Long: `Merges the history of every file with its history in the store on a
+backup target, then pushes the merged store back to the target. Files
+tracked by other machines stay on the target, so that every machine
+syncing with it keeps them.
+
+Deletions are not synced, as nothing is deleted from the target. A
+file which this machine stops tracking, or whose history it drops or
+purges, stays on the target as it was.
+
+A file whose history cannot be merged, such as one which was first
+committed separately on each machine, is reported as a conflict and
+left as it is, both here and on the target. A tag which the target has
+on another commit than this machine stays on the commit it has here.`,
+	Args: expectTargetArg,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target, err := openTarget(args[0])
+		if err != nil {
+			return err
+		}
+		if err = fileStore.SaveToDisk(); err != nil {
+			target.Close()
+			return err
+		}
+		report, err := backup.Sync(fileStore, target)
+		if err != nil {
+			target.Close()
+			return err
+		}
+		if err = target.Close(); err != nil {
+			return err
+		}
+		behind, diverged := 0, 0
+		synced := output.Sync{Target: target.Name(), Files: []output.FileSync{}, Skipped: []string{},
+			Conflicts: []output.SyncConflict{}}
+		for _, fileSync := range report.Files {
+			tagClashes := []output.TagClash{}
+			for _, clash := range fileSync.TagClashes {
+				tagClashes = append(tagClashes, output.TagClash{
+					Tag:     clash.Tag,
+					Kept:    clash.Kept.UUID(),
+					Dropped: clash.Dropped.UUID(),
+				})
+				color.Yellow("Kept tag %s of %s on %s, %s has it on %s", clash.Tag,
+					fileSync.DotFile.Path(), clash.Kept.UUID(), target.Name(), clash.Dropped.UUID())
+			}
+			synced.Files = append(synced.Files, output.FileSync{
+				Path:          fileSync.DotFile.Path(),
+				Added:         fileSync.Added,
+				Behind:        fileSync.Behind,
+				Diverged:      fileSync.Diverged,
+				CurrentCommit: fileSync.DotFile.CurrentHistory().UUID(),
+				RemoteTip:     fileSync.RemoteTip.UUID(),
+				TagClashes:    tagClashes,
+			})
+			if fileSync.Added != 0 {
+				fmt.Fprintf(messages, "Merged %d commits into %s\n", fileSync.Added, fileSync.DotFile.Path())
+			}
+			if fileSync.Behind {
+				behind += 1
+				color.Yellow("%s is behind: current commit is %s, %s has %s",
+					fileSync.DotFile.Path(), fileSync.DotFile.CurrentHistory().UUID(),
+					target.Name(), fileSync.RemoteTip.UUID())
+			}
+			if fileSync.Diverged {
+				diverged += 1
+				color.Yellow("%s has diverged: current commit is %s, %s has %s",
+					fileSync.DotFile.Path(), fileSync.DotFile.CurrentHistory().UUID(),
+					target.Name(), fileSync.RemoteTip.UUID())
+			}
+		}
+		for _, path := range report.Skipped {
+			color.Yellow("Skipped %s: not tracked with history on both sides", path)
+			synced.Skipped = append(synced.Skipped, path)
+		}
+		for _, conflict := range report.Conflicts {
+			color.Red("Skipped %s: %v", conflict.DotFile.Path(), conflict.Err)
+			synced.Conflicts = append(synced.Conflicts, output.SyncConflict{
+				Path:   conflict.DotFile.Path(),
+				Reason: conflict.Err.Error(),
+			})
+		}
+		if output.IsStructured() {
+			return output.Print(synced)
+		}
+		color.Green("Synced %d files with %s", len(report.Files), target.Name())
+		if behind != 0 {
+			color.Yellow("%d files are behind and need a checkout of the tip on %s", behind, target.Name())
+		}
+		if diverged != 0 {
+			color.Yellow("%d files have diverged and need a merge", diverged)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/RedDocMD/dotted/deploy"
+	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/hook"
+	"github.com/RedDocMD/dotted/output"
+	"github.com/RedDocMD/dotted/tui"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "browse dot-files and their history in a full-screen interface",
+	Long: `Lists the dot-files, and shows the history of a file as a tree with
+a preview of every commit, as its diff against the parent or as its
+whole content. Commits are checked out, tagged and made from the
+keyboard, with the same checks and hooks as apply and commit. The
+output of hooks is printed once the interface is quit.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if output.IsStructured() {
+			return fmt.Errorf("ui has no %s output", output.Format)
+		}
+		if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+			return fmt.Errorf("ui needs a terminal")
+		}
+
+		// Hooks and warnings would draw over the interface
+		var log bytes.Buffer
+		previousMessages, previousColor := messages, color.Output
+		previousStdout, previousStderr := hook.Stdout, hook.Stderr
+		messages, color.Output, hook.Stdout, hook.Stderr = &log, &log, &log, &log
+		err := tui.Run(fileStore.Files(), tui.Actions{Commit: uiCommit, Checkout: uiCheckout})
+		messages, color.Output = previousMessages, previousColor
+		hook.Stdout, hook.Stderr = previousStdout, previousStderr
+		os.Stdout.Write(log.Bytes())
+		return err
+	},
+}
+
+func uiCommit(dotFile *file.DotFile) (string, error) {
+	result, changed, err := reportCommit(dotFile, true)
+	if err != nil {
+		return "", err
+	}
+	if !changed {
+		return fmt.Sprintf("%s has no changes", dotFile.Path()), nil
+	}
+	switch result.Result {
+	case output.Skipped:
+		return fmt.Sprintf("Skipped %s: %s", result.Path, result.Reason), nil
+	case output.Committed:
+		return fmt.Sprintf("Committed %s as %s", result.Path, result.NewCommit[:8]), nil
+	default:
+		return fmt.Sprintf("Updated %s", result.Path), nil
+	}
+}
+
+// uiCheckout makes node the current commit of dotFile and applies it,
+// going back to the previous commit if the preApply hooks fail.
+func uiCheckout(dotFile *file.DotFile, node *file.HistoryNode) (string, error) {
+	previous := dotFile.CurrentHistory()
+	if err := dotFile.Checkout(node); err != nil {
+		return "", err
+	}
+	change, err := applyDotFile(dotFile, false)
+	if err != nil {
+		dotFile.Checkout(previous)
+		if isStopped(err) {
+			return fmt.Sprintf("Skipped %s: %v", dotFile.Path(), err), nil
+		}
+		return "", err
+	}
+	message := fmt.Sprintf("Checked out %s", node.UUID()[:8])
+	if change.Action != deploy.Unchanged {
+		message += fmt.Sprintf(" to %s", dotFile.Path())
+	}
+	for _, backup := range change.Backups {
+		message += fmt.Sprintf(", backed up to %s", backup)
+	}
+	return message, nil
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/output"
+	"github.com/RedDocMD/dotted/watch"
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "commit dot-files automatically whenever they are saved",
+	Long: `Watches every dot-file and commits it, or updates it for files
+without history, once it has not changed for the quiet period, so
+that the many writes of a single save make one commit. Runs until
+interrupted. Every commit is printed and appended to the watch log,
+which defaults to dotted/watch.log in the user cache directory. With
+--output json, each commit is also printed as a line of JSON.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		quiet := configs.Watch.QuietDuration()
+		if cmd.Flags().Changed("quiet-period") {
+			quiet = watchQuietPeriod
+		}
+		if quiet <= 0 {
+			quiet = watch.DefaultQuietPeriod
+		}
+		logFile, err := openWatchLog(configs.Watch.Log)
+		if err != nil {
+			return err
+		}
+		defer logFile.Close()
+
+		watcher, err := watch.New(fileStore.Files(), quiet)
+		if err != nil {
+			return err
+		}
+		for _, path := range watcher.Lost() {
+			color.Yellow("Warning: %s does not exist, watching for it to appear", path)
+		}
+		stop := make(chan struct{})
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signals
+			close(stop)
+		}()
+
+		color.Green("Watching %d files, committing after %s without changes", len(fileStore.Files()), quiet)
+		return watcher.Run(stop, func(dotFile *file.DotFile) error {
+			result, changed, err := reportCommit(dotFile, !watchNoVerify)
+			if err != nil {
+				// A file which cannot be read now may be readable after
+				// its next save, so keep watching
+				logWatch(logFile, "failed %s: %v", dotFile.Path(), err)
+				return nil
+			}
+			if !changed {
+				return nil
+			}
+			switch result.Result {
+			case output.Skipped:
+				logWatch(logFile, "skipped %s: %s", result.Path, result.Reason)
+			case output.Committed:
+				logWatch(logFile, "committed %s as %s", result.Path, result.NewCommit)
+			case output.Updated:
+				logWatch(logFile, "updated %s", result.Path)
+			}
+			if result.Result != output.Skipped {
+				if err = fileStore.SaveToDisk(); err != nil {
+					return err
+				}
+			}
+			if output.IsStructured() {
+				now := time.Now()
+				result.Time = &now
+				return output.PrintStream(result)
+			}
+			return nil
+		}, func(err error) {
+			logWatch(logFile, "error: %v", err)
+		})
+	},
+}
+
+var watchQuietPeriod time.Duration
+var watchNoVerify bool
+
+func initWatchCommand() {
+	watchCmd.Flags().DurationVar(&watchQuietPeriod, "quiet-period", watch.DefaultQuietPeriod,
+		"how long a file must be left alone before it is committed")
+	watchCmd.Flags().BoolVar(&watchNoVerify, "no-verify", false,
+		"commit files without validating their syntax or running preCommit hooks")
+}
+
+func openWatchLog(path string) (*os.File, error) {
+	if len(path) == 0 {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to open watch log")
+		}
+		path = filepath.Join(cacheDir, "dotted", "watch.log")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, errors.Wrap(err, "failed to open watch log")
+	}
+	logFile, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open watch log")
+	}
+	return logFile, nil
+}
+
+func logWatch(logFile *os.File, format string, args ...interface{}) {
+	line := fmt.Sprintf("%s %s\n", time.Now().Format(time.RFC3339), fmt.Sprintf(format, args...))
+	fmt.Fprint(messages, line)
+	logFile.WriteString(line)
+}
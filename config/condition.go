@@ -0,0 +1,108 @@
+package config
+
+import (
+	"os"
+	"runtime"
+)
+
+// Condition limits an entry to some hosts. Every field which is set
+// must match, and a list matches if any of its items does.
+type Condition struct {
+	Hostname StringList `yaml:",omitempty"`
+	OS       StringList `yaml:",omitempty"` // As in GOOS, such as linux or darwin
+	// Environment variables with the given values. An empty value
+	// only requires the variable to be set.
+	Env map[string]string `yaml:",omitempty"`
+}
+
+// Host is what conditions are checked against.
+type Host struct {
+	Hostname  string
+	OS        string
+	LookupEnv func(key string) (string, bool)
+}
+
+// CurrentHost describes this host.
+func CurrentHost() Host {
+	hostname, _ := os.Hostname()
+	return Host{
+		Hostname:  hostname,
+		OS:        runtime.GOOS,
+		LookupEnv: os.LookupEnv,
+	}
+}
+
+// Matches reports whether host satisfies the condition.
+func (condition *Condition) Matches(host Host) bool {
+	if condition == nil {
+		return true
+	}
+	if len(condition.Hostname) != 0 && !condition.Hostname.Contains(host.Hostname) {
+		return false
+	}
+	if len(condition.OS) != 0 && !condition.OS.Contains(host.OS) {
+		return false
+	}
+	for key, expected := range condition.Env {
+		value, ok := host.LookupEnv(key)
+		if !ok || (len(expected) != 0 && value != expected) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsActive reports whether entry applies to host.
+func (entry FileEntry) IsActive(host Host) bool {
+	return entry.When.Matches(host)
+}
+
+// ActiveEntries returns the entries of the withHistory and
+// withoutHistory lists which apply to host.
+func (config *Config) ActiveEntries(host Host) ([]FileEntry, []FileEntry) {
+	return activeEntries(config.WithHistory, host), activeEntries(config.WithoutHistory, host)
+}
+
+func activeEntries(entries []FileEntry, host Host) []FileEntry {
+	var active []FileEntry
+	for _, entry := range entries {
+		if entry.IsActive(host) {
+			active = append(active, entry)
+		}
+	}
+	return active
+}
+
+// StringList is a list of strings, which can be written as a single
+// string in the config when it has one item.
+type StringList []string
+
+func (list *StringList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var single string
+	if err := unmarshal(&single); err == nil {
+		*list = StringList{single}
+		return nil
+	}
+	var items []string
+	if err := unmarshal(&items); err != nil {
+		return err
+	}
+	*list = items
+	return nil
+}
+
+func (list StringList) MarshalYAML() (interface{}, error) {
+	if len(list) == 1 {
+		return list[0], nil
+	}
+	return []string(list), nil
+}
+
+func (list StringList) Contains(item string) bool {
+	for _, listItem := range list {
+		if listItem == item {
+			return true
+		}
+	}
+	return false
+}
@@ -3,8 +3,11 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/RedDocMD/dotted/fs"
+	"github.com/RedDocMD/dotted/validate"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
 )
@@ -13,17 +16,85 @@ var Fs = fs.OsFs
 var Afs = fs.OsAfs
 
 type Config struct {
-	Name           string      `yaml:"name"`
-	WithHistory    []FileEntry `yaml:"withHistory"`
-	WithoutHistory []FileEntry `yaml:"withoutHistory"`
-	StoreLocation  string      `yaml:"storeLocation"`
+	Name           string        `yaml:"name"`
+	WithHistory    []FileEntry   `yaml:"withHistory"`
+	WithoutHistory []FileEntry   `yaml:"withoutHistory"`
+	StoreLocation  string        `yaml:"storeLocation"`
+	KeyFile        string        `yaml:"keyFile"`
+	Deploy         string        `yaml:"deploy"`
+	Targets        []TargetEntry `yaml:"targets"`
+	// Values available to templates
+	Variables map[string]interface{} `yaml:"variables"`
+	Watch     WatchConfig            `yaml:"watch"`
+	Hooks     Hooks                  `yaml:"hooks"` // Run for every file
+	// Command paging long output instead of $PAGER, or none to turn
+	// paging off
+	Pager string `yaml:"pager"`
+}
+
+// WatchConfig configures dtd watch.
+type WatchConfig struct {
+	// How long a file must be left alone before it is committed,
+	// as a duration such as 2s
+	QuietPeriod string `yaml:"quietPeriod"`
+	Log         string // Path of the log of what was committed
+}
+
+// QuietDuration parses QuietPeriod, which is zero if not set.
+func (watch WatchConfig) QuietDuration() time.Duration {
+	quiet, _ := time.ParseDuration(watch.QuietPeriod)
+	return quiet
 }
 
 type FileEntry struct {
-	Path     string
-	Mnemonic string
+	Path      string
+	Mnemonic  string `yaml:",omitempty"`
+	Encrypted bool   `yaml:",omitempty"`
+	Deploy    string `yaml:",omitempty"` // Overrides Deploy of the config
+	// Path relative to $HOME of a template rendered to Path
+	Template string `yaml:",omitempty"`
+	// Hosts the entry applies to, which is every host if nil
+	When  *Condition `yaml:",omitempty"`
+	Hooks *Hooks     `yaml:",omitempty"` // Run after the hooks of the config
+	// Syntax the file is validated against before a commit, which is
+	// found by its extension if empty and skipped if none
+	Format string `yaml:",omitempty"`
+}
+
+// Ways of deploying a dot-file to its path
+const (
+	CopyDeploy    = "copy"    // A copy of the stored content
+	SymlinkDeploy = "symlink" // A symlink into the work tree of the store
+)
+
+// TargetEntry configures a backup target. The fields
+// other than Name and Type which are used depend on Type.
+type TargetEntry struct {
+	Name       string
+	Type       string
+	Path       string
+	URL        string `yaml:"url"`
+	Branch     string
+	Bucket     string
+	Prefix     string
+	Region     string
+	Endpoint   string
+	PathStyle  bool `yaml:"pathStyle"`
+	Host       string
+	Port       int
+	User       string
+	KeyPath    string `yaml:"keyPath"`
+	KnownHosts string `yaml:"knownHosts"`
+	RemoteDir  string `yaml:"remoteDir"`
 }
 
+const (
+	DirTarget  = "dir"
+	GitTarget  = "git"
+	S3Target   = "s3"
+	SFTPTarget = "sftp"
+)
+
 func ReadConfig(path string) (*Config, error) {
 	configBytes, err := Afs.ReadFile(path)
 	if err != nil {
@@ -38,6 +109,24 @@ func ReadConfig(path string) (*Config, error) {
 		return nil, err
 	} else {
 		config.StoreLocation = Fs.Abs(config.StoreLocation)
+		if len(config.KeyFile) != 0 {
+			config.KeyFile = Fs.Abs(config.KeyFile)
+		}
+		if len(config.Watch.Log) != 0 {
+			config.Watch.Log = Fs.Abs(config.Watch.Log)
+		}
+		for i := range config.Targets {
+			target := &config.Targets[i]
+			if len(target.Path) != 0 {
+				target.Path = Fs.Abs(target.Path)
+			}
+			if len(target.KeyPath) != 0 {
+				target.KeyPath = Fs.Abs(target.KeyPath)
+			}
+			if len(target.KnownHosts) != 0 {
+				target.KnownHosts = Fs.Abs(target.KnownHosts)
+			}
+		}
 		return &config, nil
 	}
 }
@@ -54,9 +143,119 @@ func (config Config) validateConfig() error {
 			return errors.New(fmt.Sprintf("invalid config: %s is an absolute path, all paths must be relative to $HOME", entry.Path))
 		}
 	}
+	if err := validateDeploy(config.Deploy); err != nil {
+		return err
+	}
+	for _, entries := range [][]FileEntry{config.WithHistory, config.WithoutHistory} {
+		for _, entry := range entries {
+			if err := validateDeploy(entry.Deploy); err != nil {
+				return errors.WithMessagef(err, "entry %s", entry.Path)
+			}
+			if len(entry.Format) != 0 && !validate.IsKnown(entry.Format) {
+				return fmt.Errorf("invalid config: entry %s: unknown format %q, expected one of %s or %s",
+					entry.Path, entry.Format, strings.Join(validate.Formats(), ", "), validate.None)
+			}
+			if len(entry.Template) == 0 {
+				continue
+			}
+			if Fs.IsAbs(entry.Template) {
+				return fmt.Errorf("invalid config: entry %s: template %s is an absolute path", entry.Path, entry.Template)
+			}
+			if entry.Deploy == SymlinkDeploy {
+				return fmt.Errorf("invalid config: entry %s: templates cannot be deployed as symlinks", entry.Path)
+			}
+		}
+	}
+	if len(config.Watch.QuietPeriod) != 0 {
+		quiet, err := time.ParseDuration(config.Watch.QuietPeriod)
+		if err != nil || quiet <= 0 {
+			return fmt.Errorf("invalid config: invalid quiet period %q", config.Watch.QuietPeriod)
+		}
+	}
+	targetNames := make(map[string]struct{})
+	for _, target := range config.Targets {
+		if err := target.validate(); err != nil {
+			return err
+		}
+		if _, ok := targetNames[target.Name]; ok {
+			return fmt.Errorf("invalid config: duplicate target %s", target.Name)
+		}
+		targetNames[target.Name] = struct{}{}
+	}
 	return nil
 }
 
+func validateDeploy(deploy string) error {
+	switch deploy {
+	case "", CopyDeploy, SymlinkDeploy:
+		return nil
+	default:
+		return fmt.Errorf("invalid config: unknown deploy mode %q", deploy)
+	}
+}
+
+// DeployMode returns how the file of entry is deployed, which is
+// a copy unless the entry or the config say otherwise. Templates
+// are always rendered to a copy.
+func (config *Config) DeployMode(entry FileEntry) string {
+	if len(entry.Template) != 0 {
+		return CopyDeploy
+	}
+	if len(entry.Deploy) != 0 {
+		return entry.Deploy
+	}
+	if len(config.Deploy) != 0 {
+		return config.Deploy
+	}
+	return CopyDeploy
+}
+
+func (target TargetEntry) validate() error {
+	if len(target.Name) == 0 {
+		return errors.New("invalid config: target with empty name")
+	}
+	switch target.Type {
+	case DirTarget:
+		if len(target.Path) == 0 {
+			return fmt.Errorf("invalid config: target %s: empty path", target.Name)
+		}
+	case GitTarget:
+		if len(target.URL) == 0 {
+			return fmt.Errorf("invalid config: target %s: empty url", target.Name)
+		}
+	case S3Target:
+		if len(target.Bucket) == 0 {
+			return fmt.Errorf("invalid config: target %s: empty bucket", target.Name)
+		}
+	case SFTPTarget:
+		if len(target.Host) == 0 {
+			return fmt.Errorf("invalid config: target %s: empty host", target.Name)
+		}
+		if len(target.User) == 0 {
+			return fmt.Errorf("invalid config: target %s: empty user", target.Name)
+		}
+		if len(target.KeyPath) == 0 {
+			return fmt.Errorf("invalid config: target %s: empty key path", target.Name)
+		}
+		if len(target.RemoteDir) == 0 {
+			return fmt.Errorf("invalid config: target %s: empty remote dir", target.Name)
+		}
+	default:
+		return fmt.Errorf("invalid config: target %s: unknown type %q", target.Name, target.Type)
+	}
+	return nil
+}
+
+// TargetWithName returns the backup target called name.
+func (config *Config) TargetWithName(name string) (TargetEntry, error) {
+	for _, target := range config.Targets {
+		if target.Name == name {
+			return target, nil
+		}
+	}
+	return TargetEntry{}, fmt.Errorf("no backup target named %s", name)
+}
+
 func (config *Config) IsStoreAvailable() bool {
 	stat, err := Fs.Stat(config.StoreLocation)
 	if os.IsNotExist(err) {
@@ -4,6 +4,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/RedDocMD/dotted/fs"
 	"github.com/stretchr/testify/assert"
@@ -76,6 +77,21 @@ func (suite *ConfigSuite) TestParseInvalidConfig() {
 	}
 	_, err = ReadConfig(configPath)
 	assert.NotNil(t, err)
+	configPath = filepath.Join("testdata", "invalid_config5.yml")
+	_, err = ReadConfig(configPath)
+	assert.NotNil(t, err)
+	configPath = filepath.Join("testdata", "invalid_config6.yml")
+	_, err = ReadConfig(configPath)
+	assert.NotNil(t, err)
+	configPath = filepath.Join("testdata", "invalid_config7.yml")
+	_, err = ReadConfig(configPath)
+	assert.NotNil(t, err)
+	configPath = filepath.Join("testdata", "invalid_config8.yml")
+	_, err = ReadConfig(configPath)
+	assert.NotNil(t, err)
+	configPath = filepath.Join("testdata", "invalid_config9.yml")
+	_, err = ReadConfig(configPath)
+	assert.NotNil(t, err)
 }
 
 func (suite *ConfigSuite) TestParseIncompleteConfig() {
@@ -104,6 +120,214 @@ func (suite *ConfigSuite) TestParseIncompleteConfig() {
 	assert.Equal(expectedConfig, config)
 }
 
+func (suite *ConfigSuite) TestParseEncryptedConfig() {
+	assert := assert.New(suite.T())
+	configPath := filepath.Join("testdata", "config3.yml")
+	config, err := ReadConfig(configPath)
+	assert.Equal(err, nil)
+	expectedConfig := &Config{
+		Name: "Linux",
+		WithHistory: []FileEntry{
+			{
+				Path:      ".config/fish/config.fish",
+				Mnemonic:  "fish",
+				Encrypted: true,
+			},
+		},
+		WithoutHistory: []FileEntry{
+			{
+				Path:      ".netrc",
+				Mnemonic:  "",
+				Encrypted: true,
+			},
+		},
+		StoreLocation: Fs.Abs(".config/dotted/store"),
+		KeyFile:       Fs.Abs(".config/dotted/key.txt"),
+		Targets: []TargetEntry{
+			{
+				Name: "drive",
+				Type: "dir",
+				Path: "/mnt/drive/dotted",
+			},
+		},
+	}
+	assert.Equal(expectedConfig, config)
+}
+
+func (suite *ConfigSuite) TestDeployMode() {
+	assert := assert.New(suite.T())
+	config, err := ReadConfig(filepath.Join("testdata", "config4.yml"))
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	assert.Equal(SymlinkDeploy, config.DeployMode(config.WithHistory[0]))
+	assert.Equal(CopyDeploy, config.DeployMode(config.WithHistory[1]))
+	assert.Equal(SymlinkDeploy, config.DeployMode(config.WithoutHistory[0]))
+	assert.Equal(CopyDeploy, config.DeployMode(config.WithoutHistory[1]))
+	assert.Equal(".config/alacritty/alacritty.yml.tmpl", config.WithoutHistory[1].Template)
+	assert.Equal("none", config.WithHistory[1].Format)
+	assert.Equal(11, config.Variables["fontSize"])
+	assert.Equal(500*time.Millisecond, config.Watch.QuietDuration())
+	assert.Equal(Fs.Abs(".cache/dotted/watch.log"), config.Watch.Log)
+	assert.Equal("less -RS", config.Pager)
+	config.Deploy = ""
+	assert.Equal(CopyDeploy, config.DeployMode(config.WithoutHistory[0]))
+}
+
+func (suite *ConfigSuite) TestConditions() {
+	assert := assert.New(suite.T())
+	config, err := ReadConfig(filepath.Join("testdata", "config4.yml"))
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	env := map[string]string{"WAYLAND_DISPLAY": "wayland-1"}
+	host := Host{
+		Hostname: "laptop",
+		OS:       "linux",
+		LookupEnv: func(key string) (string, bool) {
+			value, ok := env[key]
+			return value, ok
+		},
+	}
+	sway := config.WithHistory[2]
+	assert.Equal(&Condition{
+		Hostname: StringList{"laptop", "desktop"},
+		OS:       StringList{"linux"},
+		Env:      map[string]string{"WAYLAND_DISPLAY": ""},
+	}, sway.When)
+	assert.True(sway.IsActive(host))
+	withHistory, withoutHistory := config.ActiveEntries(host)
+	assert.Len(withHistory, 3)
+	assert.Len(withoutHistory, 2)
+
+	delete(env, "WAYLAND_DISPLAY")
+	assert.False(sway.IsActive(host))
+	env["WAYLAND_DISPLAY"] = "wayland-1"
+	host.OS = "darwin"
+	assert.False(sway.IsActive(host))
+	host.OS = "linux"
+	host.Hostname = "server"
+	assert.False(sway.IsActive(host))
+	withHistory, _ = config.ActiveEntries(host)
+	assert.Len(withHistory, 2)
+
+	sway.When.Env["WAYLAND_DISPLAY"] = "wayland-0"
+	host.Hostname = "desktop"
+	assert.False(sway.IsActive(host))
+}
+
+func (suite *ConfigSuite) TestHooks() {
+	assert := assert.New(suite.T())
+	config, err := ReadConfig(filepath.Join("testdata", "config4.yml"))
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	host := Host{Hostname: "laptop", OS: "linux", LookupEnv: func(string) (string, bool) { return "", false }}
+	assert.Equal(Hooks{
+		PreCommit:  StringList{`git diff --no-index --check /dev/null "$DOTTED_SOURCE"`},
+		PostCommit: StringList{`notify-send "committed $DOTTED_MNEMONIC"`},
+	}, config.HooksFor(".config/nvim/init.lua", host))
+	assert.Equal(Hooks{
+		PreCommit:  StringList{`git diff --no-index --check /dev/null "$DOTTED_SOURCE"`},
+		PostCommit: StringList{`notify-send "committed $DOTTED_MNEMONIC"`, "tmux source-file ~/.tmux.conf"},
+		PreApply:   StringList{"test -d ~/.config/tmux"},
+	}, config.HooksFor(".tmux.conf", host))
+	assert.Equal(config.Hooks, config.HooksFor(".bashrc", host))
+
+	// Each host gets the hooks of its own entry for a path
+	config.Hooks = Hooks{}
+	config.WithHistory = []FileEntry{{
+		Path:  ".zshrc",
+		When:  &Condition{Hostname: StringList{"desktop"}},
+		Hooks: &Hooks{PostApply: StringList{"echo desktop"}},
+	}}
+	config.WithoutHistory = []FileEntry{{
+		Path:   ".zshrc",
+		When:   &Condition{Hostname: StringList{"laptop"}},
+		Hooks:  &Hooks{PostApply: StringList{"echo laptop"}},
+		Format: "yaml",
+	}}
+	assert.Equal(Hooks{PostApply: StringList{"echo laptop"}}, config.HooksFor(".zshrc", host))
+	entry, ok := config.EntryWithPath(".zshrc", host)
+	assert.True(ok)
+	assert.Equal("yaml", entry.Format)
+	host.Hostname = "server"
+	_, ok = config.EntryWithPath(".zshrc", host)
+	assert.False(ok)
+}
+
 func TestSuite(t *testing.T) {
 	suite.Run(t, &ConfigSuite{})
 }
+
+func (suite *ConfigSuite) TestEditConfig() {
+	assert := assert.New(suite.T())
+	configBytes, err := Afs.ReadFile(filepath.Join("testdata", "config2.yml"))
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	configPath := filepath.Join(suite.T().TempDir(), "dotted.yml")
+	Afs.WriteFile(configPath, configBytes, 0644)
+
+	err = AddEntry(configPath, FileEntry{Path: ".tmux.conf", Mnemonic: "tmux"}, false)
+	assert.Nil(err)
+	err = AddEntry(configPath, FileEntry{Path: ".netrc", Encrypted: true}, true)
+	assert.Nil(err)
+	config, err := ReadConfig(configPath)
+	assert.Nil(err)
+	assert.Equal([]FileEntry{{Path: ".tmux.conf", Mnemonic: "tmux"}}, config.WithoutHistory)
+	assert.Len(config.WithHistory, 4)
+	assert.Equal(FileEntry{Path: ".netrc", Encrypted: true}, config.WithHistory[3])
+
+	removed, err := RemoveEntry(configPath, ".bashrc")
+	assert.Nil(err)
+	assert.True(removed)
+	removed, err = RemoveEntry(configPath, ".bashrc")
+	assert.Nil(err)
+	assert.False(removed)
+	config, err = ReadConfig(configPath)
+	assert.Nil(err)
+	assert.Len(config.WithHistory, 3)
+	assert.Equal("Linux", config.Name)
+}
+
+func (suite *ConfigSuite) TestMoveEntry() {
+	assert := assert.New(suite.T())
+	configPath := filepath.Join(suite.T().TempDir(), "dotted.yml")
+	Afs.WriteFile(configPath, []byte(`name: Linux
+
+withoutHistory:
+  - path: .zshrc
+    mnemonic: zsh
+    deploy: symlink
+    format: yaml
+    # Only on the laptop
+    when:
+      hostname: laptop
+    hooks:
+      postApply: exec zsh
+  - path: .tmux.conf
+
+storeLocation: .config/dotted/store
+`), 0644)
+
+	moved, err := MoveEntry(configPath, ".zshrc", true)
+	assert.Nil(err)
+	assert.True(moved)
+	moved, err = MoveEntry(configPath, ".zshrc", true)
+	assert.Nil(err)
+	assert.False(moved)
+	config, err := ReadConfig(configPath)
+	assert.Nil(err)
+	assert.Equal([]FileEntry{{Path: ".tmux.conf"}}, config.WithoutHistory)
+	assert.Equal([]FileEntry{{
+		Path:     ".zshrc",
+		Mnemonic: "zsh",
+		Deploy:   SymlinkDeploy,
+		Format:   "yaml",
+		When:     &Condition{Hostname: StringList{"laptop"}},
+		Hooks:    &Hooks{PostApply: StringList{"exec zsh"}},
+	}}, config.WithHistory)
+	configBytes, _ := Afs.ReadFile(configPath)
+	assert.Contains(string(configBytes), "# Only on the laptop")
+}
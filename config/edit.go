@@ -0,0 +1,134 @@
+package config
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// The functions below edit the config file in place, so that
+// comments and the layout of the rest of the file are kept.
+
+// AddEntry appends entry to the withHistory or withoutHistory list
+// of the config file at path.
+func AddEntry(path string, entry FileEntry, withHistory bool) error {
+	document, err := readDocument(path)
+	if err != nil {
+		return errors.WithMessage(err, "failed to add entry to config")
+	}
+	var entryNode yaml.Node
+	if err = entryNode.Encode(entry); err != nil {
+		return errors.Wrap(err, "failed to add entry to config")
+	}
+	list := listNode(document, listKey(withHistory))
+	list.Content = append(list.Content, &entryNode)
+	return errors.WithMessage(writeDocument(path, document), "failed to add entry to config")
+}
+
+// RemoveEntry removes the entry for entryPath from both lists of the
+// config file at path and returns whether there was one.
+func RemoveEntry(path, entryPath string) (bool, error) {
+	document, err := readDocument(path)
+	if err != nil {
+		return false, errors.WithMessage(err, "failed to remove entry from config")
+	}
+	removed := false
+	for _, withHistory := range []bool{true, false} {
+		list := listNode(document, listKey(withHistory))
+		var kept []*yaml.Node
+		for _, entryNode := range list.Content {
+			var entry FileEntry
+			if err := entryNode.Decode(&entry); err == nil && entry.Path == entryPath {
+				removed = true
+				continue
+			}
+			kept = append(kept, entryNode)
+		}
+		list.Content = kept
+	}
+	if !removed {
+		return false, nil
+	}
+	return true, errors.WithMessage(writeDocument(path, document), "failed to remove entry from config")
+}
+
+// MoveEntry moves the entries for entryPath to the withHistory or
+// withoutHistory list of the config file at path, as they are, and
+// returns whether there were any in the other list.
+func MoveEntry(path, entryPath string, withHistory bool) (bool, error) {
+	document, err := readDocument(path)
+	if err != nil {
+		return false, errors.WithMessage(err, "failed to move entry in config")
+	}
+	from := listNode(document, listKey(!withHistory))
+	var kept, moved []*yaml.Node
+	for _, entryNode := range from.Content {
+		var entry FileEntry
+		if err := entryNode.Decode(&entry); err == nil && entry.Path == entryPath {
+			moved = append(moved, entryNode)
+			continue
+		}
+		kept = append(kept, entryNode)
+	}
+	if len(moved) == 0 {
+		return false, nil
+	}
+	from.Content = kept
+	to := listNode(document, listKey(withHistory))
+	to.Content = append(to.Content, moved...)
+	return true, errors.WithMessage(writeDocument(path, document), "failed to move entry in config")
+}
+
+func listKey(withHistory bool) string {
+	if withHistory {
+		return "withHistory"
+	}
+	return "withoutHistory"
+}
+
+func readDocument(path string) (*yaml.Node, error) {
+	configBytes, err := Afs.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read config")
+	}
+	var document yaml.Node
+	if err = yaml.Unmarshal(configBytes, &document); err != nil {
+		return nil, errors.Wrap(err, "failed to parse config")
+	}
+	if document.Kind != yaml.DocumentNode || len(document.Content) != 1 || document.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("failed to parse config: expected a mapping")
+	}
+	return document.Content[0], nil
+}
+
+func writeDocument(path string, document *yaml.Node) error {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(document); err != nil {
+		return errors.Wrap(err, "failed to write config")
+	}
+	if err := encoder.Close(); err != nil {
+		return errors.Wrap(err, "failed to write config")
+	}
+	return errors.Wrap(Afs.WriteFile(path, buf.Bytes(), 0644), "failed to write config")
+}
+
+// listNode returns the sequence under key in mapping, adding an empty
+// one if key is missing or null.
+func listNode(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			value := mapping.Content[i+1]
+			if value.Kind != yaml.SequenceNode {
+				*value = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			}
+			return value
+		}
+	}
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+	value := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	mapping.Content = append(mapping.Content, keyNode, value)
+	return value
+}
@@ -0,0 +1,52 @@
+package config
+
+// Hooks are shell commands run around commits and applies of a
+// file. Each event takes a single command or a list of them.
+type Hooks struct {
+	// Run before a changed file is committed. If one fails, the
+	// file is not committed.
+	PreCommit  StringList `yaml:"preCommit,omitempty"`
+	PostCommit StringList `yaml:"postCommit,omitempty"`
+	// Run before a file is written to its path. If one fails, the
+	// file is not applied.
+	PreApply  StringList `yaml:"preApply,omitempty"`
+	PostApply StringList `yaml:"postApply,omitempty"`
+}
+
+// EntryWithPath returns the entry for path, which is relative to
+// $HOME, from either list. Only the entries which apply to host are
+// looked at, as other hosts may have their own entries for path.
+func (config *Config) EntryWithPath(path string, host Host) (FileEntry, bool) {
+	withHistory, withoutHistory := config.ActiveEntries(host)
+	for _, entries := range [][]FileEntry{withHistory, withoutHistory} {
+		for _, entry := range entries {
+			if entry.Path == path {
+				return entry, true
+			}
+		}
+	}
+	return FileEntry{}, false
+}
+
+// HooksFor returns the hooks of the file at path, which is relative
+// to $HOME, on host. The global hooks of each event run before those
+// of the entry.
+func (config *Config) HooksFor(path string, host Host) Hooks {
+	hooks := config.Hooks
+	entry, ok := config.EntryWithPath(path, host)
+	if !ok || entry.Hooks == nil {
+		return hooks
+	}
+	return Hooks{
+		PreCommit:  joinLists(hooks.PreCommit, entry.Hooks.PreCommit),
+		PostCommit: joinLists(hooks.PostCommit, entry.Hooks.PostCommit),
+		PreApply:   joinLists(hooks.PreApply, entry.Hooks.PreApply),
+		PostApply:  joinLists(hooks.PostApply, entry.Hooks.PostApply),
+	}
+}
+
+func joinLists(first, second StringList) StringList {
+	var joined StringList
+	joined = append(joined, first...)
+	return append(joined, second...)
+}
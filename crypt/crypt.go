@@ -0,0 +1,109 @@
+package crypt
+
+import (
+	"bytes"
+	"io"
+
+	"filippo.io/age"
+	"github.com/RedDocMD/dotted/fs"
+	"github.com/pkg/errors"
+)
+
+var Fs = fs.OsFs
+var Afs = fs.OsAfs
+
+// PassphraseVariable is the environment variable from which the
+// passphrase is read when no key file is configured.
+const PassphraseVariable = "DOTTED_PASSPHRASE"
+
+// ScryptWorkFactor is the log2 of the scrypt work factor used when
+// encrypting with a passphrase.
+var ScryptWorkFactor = 18
+
+// Cipher encrypts and decrypts the on-disk representation of dot-files.
+type Cipher interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+type ageCipher struct {
+	recipients []age.Recipient
+	identities []age.Identity
+}
+
+// NewPassphraseCipher creates a cipher which derives its key from
+// passphrase with scrypt.
+func NewPassphraseCipher(passphrase string) (Cipher, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("failed to create cipher: empty passphrase")
+	}
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cipher")
+	}
+	recipient.SetWorkFactor(ScryptWorkFactor)
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cipher")
+	}
+	cipher := &ageCipher{
+		recipients: []age.Recipient{recipient},
+		identities: []age.Identity{identity},
+	}
+	return cipher, nil
+}
+
+// NewKeyFileCipher creates a cipher from the X25519 identities in the
+// age key file at path.
+func NewKeyFileCipher(path string) (Cipher, error) {
+	keyFile, err := Afs.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cipher")
+	}
+	defer keyFile.Close()
+	identities, err := age.ParseIdentities(keyFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create cipher: invalid key file %s", path)
+	}
+	var recipients []age.Recipient
+	for _, identity := range identities {
+		if x25519, ok := identity.(*age.X25519Identity); ok {
+			recipients = append(recipients, x25519.Recipient())
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, errors.Errorf("failed to create cipher: no X25519 identity in %s", path)
+	}
+	cipher := &ageCipher{
+		recipients: recipients,
+		identities: identities,
+	}
+	return cipher, nil
+}
+
+func (cipher *ageCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer, err := age.Encrypt(&buf, cipher.recipients...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encrypt")
+	}
+	if _, err = writer.Write(plaintext); err != nil {
+		return nil, errors.Wrap(err, "failed to encrypt")
+	}
+	if err = writer.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to encrypt")
+	}
+	return buf.Bytes(), nil
+}
+
+func (cipher *ageCipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	reader, err := age.Decrypt(bytes.NewReader(ciphertext), cipher.identities...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt")
+	}
+	plaintext, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt")
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,79 @@
+package crypt
+
+import (
+	"testing"
+
+	"filippo.io/age"
+	"github.com/RedDocMD/dotted/fs"
+	"github.com/stretchr/testify/suite"
+)
+
+type CryptSuite struct {
+	suite.Suite
+	workFactor int
+}
+
+const secret = `machine api.example.com login dknite password hunter2`
+
+func (suite *CryptSuite) SetupSuite() {
+	Fs = fs.MockFs
+	Afs = fs.MockAfs
+	suite.workFactor = ScryptWorkFactor
+	ScryptWorkFactor = 10
+}
+
+func (suite *CryptSuite) TearDownSuite() {
+	Fs = fs.OsFs
+	Afs = fs.OsAfs
+	ScryptWorkFactor = suite.workFactor
+}
+
+func (suite *CryptSuite) TearDownTest() {
+	Afs.RemoveAll("/")
+}
+
+func TestCryptSuite(t *testing.T) {
+	suite.Run(t, &CryptSuite{})
+}
+
+func (suite *CryptSuite) TestPassphraseRoundTrip() {
+	cipher, err := NewPassphraseCipher("correct horse battery staple")
+	suite.Nil(err)
+	ciphertext, err := cipher.Encrypt([]byte(secret))
+	suite.Nil(err)
+	suite.NotContains(string(ciphertext), "hunter2")
+	plaintext, err := cipher.Decrypt(ciphertext)
+	suite.Nil(err)
+	suite.Equal(secret, string(plaintext))
+
+	wrongCipher, err := NewPassphraseCipher("incorrect horse")
+	suite.Nil(err)
+	_, err = wrongCipher.Decrypt(ciphertext)
+	suite.NotNil(err)
+
+	_, err = NewPassphraseCipher("")
+	suite.NotNil(err)
+}
+
+func (suite *CryptSuite) TestKeyFileRoundTrip() {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	keyFile := "# created for test\n" + identity.String() + "\n"
+	Afs.WriteFile("/home/dknite/key.txt", []byte(keyFile), 0600)
+
+	cipher, err := NewKeyFileCipher("/home/dknite/key.txt")
+	suite.Nil(err)
+	ciphertext, err := cipher.Encrypt([]byte(secret))
+	suite.Nil(err)
+	plaintext, err := cipher.Decrypt(ciphertext)
+	suite.Nil(err)
+	suite.Equal(secret, string(plaintext))
+
+	_, err = NewKeyFileCipher("/home/dknite/missing.txt")
+	suite.NotNil(err)
+	Afs.WriteFile("/home/dknite/bad.txt", []byte("not a key"), 0600)
+	_, err = NewKeyFileCipher("/home/dknite/bad.txt")
+	suite.NotNil(err)
+}
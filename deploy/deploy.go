@@ -0,0 +1,204 @@
+package deploy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/fs"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+var Fs = fs.OsFs
+var Afs = fs.OsAfs
+
+// Action is what applying a dot-file does to the file at its path.
+type Action int
+
+const (
+	Unchanged Action = iota // The file already has the stored content
+	Created                 // There was no file at the path
+	Replaced                // The file differed and was backed up
+)
+
+func (action Action) String() string {
+	switch action {
+	case Created:
+		return "create"
+	case Replaced:
+		return "replace"
+	default:
+		return "unchanged"
+	}
+}
+
+// Change describes applying a dot-file.
+type Change struct {
+	DotFile *file.DotFile
+	Action  Action
+	Backups []string // Paths replaced files were moved to
+}
+
+// BackupSuffix starts the suffix added to the path of a file which is
+// replaced by Apply, followed by the time of the backup.
+const BackupSuffix = ".dotted-backup-"
+
+// Apply writes the stored content of dotFile to its path, which is
+// the current node for a file with history, creating its parent
+// directories. A file deployed as a symlink has the content written
+// to its work path instead, with a symlink to it at its path. A
+// template has its source written to its work path and is rendered
+// to its path. A differing file already in the way is first moved
+// aside to a backup next to it. Nothing is written if dryRun is set,
+// but the returned change is what would be done.
+func Apply(dotFile *file.DotFile, dryRun bool) (Change, error) {
+	change := Change{DotFile: dotFile}
+	action, err := writeContent(dotFile, dotFile.WorkPath(), dotFile.Content(), &change, dryRun)
+	if err != nil {
+		return change, err
+	}
+	change.Action = action
+	if dotFile.IsSymlinked() {
+		action, err = link(dotFile, &change, dryRun)
+	} else if dotFile.IsTemplate() {
+		var rendered string
+		rendered, err = dotFile.Rendered()
+		if err != nil {
+			return change, errors.WithMessage(err, "failed to apply")
+		}
+		action, err = writeContent(dotFile, dotFile.Path(), rendered, &change, dryRun)
+	}
+	if err != nil {
+		return change, err
+	}
+	if action > change.Action {
+		change.Action = action
+	}
+	return change, nil
+}
+
+// writeContent writes content, which belongs to dotFile, to path.
+func writeContent(dotFile *file.DotFile, path, content string, change *Change, dryRun bool) (Action, error) {
+	mode := os.FileMode(0644)
+	if dotFile.IsEncrypted() {
+		mode = 0600
+	}
+
+	var action Action
+	stat, err := Afs.Stat(path)
+	switch {
+	case os.IsNotExist(err):
+		action = Created
+	case err != nil:
+		return action, errors.Wrapf(err, "failed to apply %s", path)
+	case stat.IsDir():
+		return action, fmt.Errorf("failed to apply %s: is a directory", path)
+	default:
+		existing, err := Afs.ReadFile(path)
+		if err != nil {
+			return action, errors.Wrapf(err, "failed to apply %s", path)
+		}
+		if string(existing) == content {
+			return Unchanged, nil
+		}
+		action = Replaced
+		mode = stat.Mode().Perm()
+	}
+	if dryRun {
+		if action == Replaced {
+			change.Backups = append(change.Backups, backupPath(path))
+		}
+		return action, nil
+	}
+
+	if err = Afs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return action, errors.Wrapf(err, "failed to apply %s", path)
+	}
+	tmpPath := path + ".dotted-tmp"
+	if err = Afs.WriteFile(tmpPath, []byte(content), mode); err != nil {
+		return action, errors.Wrapf(err, "failed to apply %s", path)
+	}
+	if action == Replaced {
+		if err = backup(path, change); err != nil {
+			Afs.Remove(tmpPath)
+			return action, err
+		}
+	}
+	if err = Afs.Rename(tmpPath, path); err != nil {
+		return action, errors.Wrapf(err, "failed to apply %s", path)
+	}
+	return action, nil
+}
+
+// link points a symlink at the path of dotFile to its work path,
+// moving anything else there aside. A regular file with the stored
+// content is replaced without a backup.
+func link(dotFile *file.DotFile, change *Change, dryRun bool) (Action, error) {
+	path := dotFile.Path()
+	state, err := Status(dotFile)
+	if err != nil {
+		return Unchanged, errors.WithMessage(err, "failed to apply")
+	}
+	var action Action
+	switch state {
+	case Clean, Modified, Broken:
+		// Already linked, and the work tree was written before
+		return Unchanged, nil
+	case Missing:
+		action = Created
+	case Unlinked:
+		existing, err := Afs.ReadFile(path)
+		if err != nil {
+			return action, errors.Wrapf(err, "failed to apply %s", path)
+		}
+		action = Replaced
+		if string(existing) == dotFile.Content() {
+			if !dryRun {
+				if err = Afs.Remove(path); err != nil {
+					return action, errors.Wrapf(err, "failed to apply %s", path)
+				}
+			}
+		} else if dryRun {
+			change.Backups = append(change.Backups, backupPath(path))
+		} else if err = backup(path, change); err != nil {
+			return action, err
+		}
+	case Hijacked:
+		action = Replaced
+		if dryRun {
+			change.Backups = append(change.Backups, backupPath(path))
+		} else if err = backup(path, change); err != nil {
+			return action, err
+		}
+	}
+	if dryRun {
+		return action, nil
+	}
+	linker, ok := Afs.Fs.(afero.Linker)
+	if !ok {
+		return action, fmt.Errorf("failed to apply %s: symlinks not supported", path)
+	}
+	if err = Afs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return action, errors.Wrapf(err, "failed to apply %s", path)
+	}
+	if err = linker.SymlinkIfPossible(dotFile.WorkPath(), path); err != nil {
+		return action, errors.Wrapf(err, "failed to apply %s", path)
+	}
+	return action, nil
+}
+
+func backupPath(path string) string {
+	return path + BackupSuffix + time.Now().Format("20060102-150405")
+}
+
+func backup(path string, change *Change) error {
+	backup := backupPath(path)
+	if err := Afs.Rename(path, backup); err != nil {
+		return errors.Wrapf(err, "failed to back up %s", path)
+	}
+	change.Backups = append(change.Backups, backup)
+	return nil
+}
@@ -0,0 +1,229 @@
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/fs"
+	"github.com/RedDocMD/dotted/render"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type DeploySuite struct {
+	suite.Suite
+}
+
+func (suite *DeploySuite) SetupSuite() {
+	Fs = fs.MockFs
+	file.Fs = fs.MockFs
+	Afs = fs.MockAfs
+	file.Afs = fs.MockAfs
+}
+
+func (suite *DeploySuite) TearDownSuite() {
+	Fs = fs.OsFs
+	file.Fs = fs.OsFs
+	Afs = fs.OsAfs
+	file.Afs = fs.OsAfs
+}
+
+func (suite *DeploySuite) TestApply() {
+	assert := suite.Assert()
+	path := "/home/dknite/.config/alacritty/alacritty.yml"
+	dotFile, err := file.NewDotFileWithContent(path, "alacritty", "font:\n  size: 11\n", true)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	change, err := Apply(dotFile, true)
+	assert.Nil(err)
+	assert.Equal(Created, change.Action)
+	exists, _ := Afs.Exists(path)
+	assert.False(exists)
+
+	change, err = Apply(dotFile, false)
+	assert.Nil(err)
+	assert.Equal(Created, change.Action)
+	buf, err := Afs.ReadFile(path)
+	assert.Nil(err)
+	assert.Equal("font:\n  size: 11\n", string(buf))
+
+	change, err = Apply(dotFile, false)
+	assert.Nil(err)
+	assert.Equal(Unchanged, change.Action)
+
+	Afs.WriteFile(path, []byte("font:\n  size: 14\n"), 0600)
+	Afs.Chmod(path, 0600)
+	change, err = Apply(dotFile, true)
+	assert.Nil(err)
+	assert.Equal(Replaced, change.Action)
+	buf, _ = Afs.ReadFile(path)
+	assert.Equal("font:\n  size: 14\n", string(buf))
+
+	change, err = Apply(dotFile, false)
+	assert.Nil(err)
+	assert.Equal(Replaced, change.Action)
+	assert.Len(change.Backups, 1)
+	assert.True(strings.HasPrefix(change.Backups[0], path+BackupSuffix))
+	buf, _ = Afs.ReadFile(path)
+	assert.Equal("font:\n  size: 11\n", string(buf))
+	stat, _ := Afs.Stat(path)
+	assert.EqualValues(0600, stat.Mode().Perm())
+	buf, err = Afs.ReadFile(change.Backups[0])
+	assert.Nil(err)
+	assert.Equal("font:\n  size: 14\n", string(buf))
+}
+
+func (suite *DeploySuite) TestApplyWithoutHistory() {
+	assert := suite.Assert()
+	path := "/home/dknite/.tmux.conf"
+	dotFile, err := file.NewDotFileWithContent(path, "tmux", "set -g mouse on\n", false)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	change, err := Apply(dotFile, false)
+	assert.Nil(err)
+	assert.Equal(Created, change.Action)
+	buf, _ := Afs.ReadFile(path)
+	assert.Equal("set -g mouse on\n", string(buf))
+
+	Afs.MkdirAll("/home/dknite/.vimrc", 0755)
+	dotFile, _ = file.NewDotFileWithContent("/home/dknite/.vimrc", "vim", "set number\n", false)
+	_, err = Apply(dotFile, false)
+	assert.NotNil(err)
+}
+
+func (suite *DeploySuite) TestStatus() {
+	assert := suite.Assert()
+	path := "/home/dknite/.bashrc"
+	dotFile, _ := file.NewDotFileWithContent(path, "bash", "alias ll='ls -l'\n", true)
+	state, err := Status(dotFile)
+	assert.Nil(err)
+	assert.Equal(Missing, state)
+	Afs.WriteFile(path, []byte("alias ll='ls -l'\n"), 0644)
+	state, _ = Status(dotFile)
+	assert.Equal(Clean, state)
+	Afs.WriteFile(path, []byte("alias ll='ls -la'\n"), 0644)
+	state, _ = Status(dotFile)
+	assert.Equal(Modified, state)
+}
+
+func TestDeploySuite(t *testing.T) {
+	suite.Run(t, new(DeploySuite))
+}
+
+func (suite *DeploySuite) TestApplyTemplate() {
+	assert := suite.Assert()
+	render.Variables = map[string]interface{}{"fontSize": 11}
+	defer func() { render.Variables = nil }()
+	path := "/home/dknite/.config/kitty/kitty.conf"
+	source := "/home/dknite/.config/kitty/kitty.conf.tmpl"
+	dotFile, _ := file.NewDotFileWithContent(path, "kitty", "font_size {{ .Vars.fontSize }}\n", true)
+	dotFile.SetTemplate(source)
+
+	change, err := Apply(dotFile, false)
+	assert.Nil(err)
+	assert.Equal(Created, change.Action)
+	buf, _ := Afs.ReadFile(path)
+	assert.Equal("font_size 11\n", string(buf))
+	buf, _ = Afs.ReadFile(source)
+	assert.Equal("font_size {{ .Vars.fontSize }}\n", string(buf))
+	state, _ := Status(dotFile)
+	assert.Equal(Clean, state)
+
+	// Output rendered with other variables is stale until applied
+	render.Variables["fontSize"] = 14
+	state, _ = Status(dotFile)
+	assert.Equal(Edited, state)
+	change, err = Apply(dotFile, false)
+	assert.Nil(err)
+	assert.Equal(Replaced, change.Action)
+	state, _ = Status(dotFile)
+	assert.Equal(Clean, state)
+
+	Afs.WriteFile(source, []byte("font_size {{ .Vars.fontSize }}\nbold_font auto\n"), 0644)
+	state, _ = Status(dotFile)
+	assert.Equal(Modified, state)
+	done, err := dotFile.AddCommit()
+	assert.Nil(err)
+	assert.True(done)
+	rendered, err := dotFile.Rendered()
+	assert.Nil(err)
+	assert.Equal("font_size 14\nbold_font auto\n", rendered)
+	state, _ = Status(dotFile)
+	assert.Equal(Edited, state)
+}
+
+func TestSymlinkDeploy(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "home", ".tmux.conf")
+	workPath := filepath.Join(dir, "store", "tree", ".tmux.conf")
+	dotFile, err := file.NewDotFileWithContent(path, "tmux", "set -g mouse on\n", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dotFile.SetWorkPath(workPath)
+
+	state, err := Status(dotFile)
+	assert.Nil(err)
+	assert.Equal(Missing, state)
+	change, err := Apply(dotFile, false)
+	assert.Nil(err)
+	assert.Equal(Created, change.Action)
+	target, err := os.Readlink(path)
+	assert.Nil(err)
+	assert.Equal(workPath, target)
+	state, _ = Status(dotFile)
+	assert.Equal(Clean, state)
+
+	// Editors write through the link into the work tree
+	os.WriteFile(path, []byte("set -g mouse off\n"), 0644)
+	state, _ = Status(dotFile)
+	assert.Equal(Modified, state)
+	done, err := dotFile.AddCommit()
+	assert.Nil(err)
+	assert.True(done)
+	state, _ = Status(dotFile)
+	assert.Equal(Clean, state)
+
+	os.Remove(workPath)
+	state, _ = Status(dotFile)
+	assert.Equal(Broken, state)
+	change, err = Apply(dotFile, false)
+	assert.Nil(err)
+	assert.Equal(Created, change.Action)
+	state, _ = Status(dotFile)
+	assert.Equal(Clean, state)
+
+	other := filepath.Join(dir, "other.conf")
+	os.WriteFile(other, []byte("set -g mouse off\n"), 0644)
+	os.Remove(path)
+	os.Symlink(other, path)
+	state, _ = Status(dotFile)
+	assert.Equal(Hijacked, state)
+	change, err = Apply(dotFile, false)
+	assert.Nil(err)
+	assert.Equal(Replaced, change.Action)
+	assert.Len(change.Backups, 1)
+	target, _ = os.Readlink(change.Backups[0])
+	assert.Equal(other, target)
+	state, _ = Status(dotFile)
+	assert.Equal(Clean, state)
+
+	// A copy with the stored content is replaced without a backup
+	os.Remove(path)
+	os.WriteFile(path, []byte("set -g mouse off\n"), 0644)
+	state, _ = Status(dotFile)
+	assert.Equal(Unlinked, state)
+	change, err = Apply(dotFile, false)
+	assert.Nil(err)
+	assert.Equal(Replaced, change.Action)
+	assert.Len(change.Backups, 0)
+	state, _ = Status(dotFile)
+	assert.Equal(Clean, state)
+}
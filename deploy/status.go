@@ -0,0 +1,125 @@
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/RedDocMD/dotted/file"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// State is how what is at the path of a dot-file compares to the
+// current version of the dot-file.
+type State int
+
+const (
+	Clean    State = iota // The content is the current version
+	Modified              // The content changed since the current version
+	Missing               // There is nothing at the path
+	Unlinked              // A regular file is where a symlink belongs
+	Broken                // The symlink points to a missing work tree file
+	Hijacked              // The symlink points outside the work tree
+	Edited                // The rendered output of a template was edited
+)
+
+func (state State) String() string {
+	switch state {
+	case Modified:
+		return "modified"
+	case Missing:
+		return "missing"
+	case Unlinked:
+		return "unlinked"
+	case Broken:
+		return "broken"
+	case Hijacked:
+		return "hijacked"
+	case Edited:
+		return "edited"
+	default:
+		return "clean"
+	}
+}
+
+// Status finds the state of dotFile. For a file deployed as a
+// symlink, the link is checked before the content of the work tree.
+// For a template, the rendered output is checked against rendering
+// the stored template before the source is checked, so that edits
+// which belong in the template are told apart from changes which
+// only come from rendering it on another host.
+func Status(dotFile *file.DotFile) (State, error) {
+	path := dotFile.Path()
+	if dotFile.IsSymlinked() {
+		state, err := linkStatus(path, dotFile.WorkPath())
+		if err != nil || state != Clean {
+			return state, err
+		}
+	}
+	if dotFile.IsTemplate() {
+		rendered, err := dotFile.Rendered()
+		if err != nil {
+			return Clean, errors.WithMessage(err, "failed to check")
+		}
+		state, err := compare(path, rendered)
+		if err != nil || state != Clean {
+			if state == Modified {
+				state = Edited
+			}
+			return state, err
+		}
+	}
+	return compare(dotFile.WorkPath(), dotFile.Content())
+}
+
+// compare checks the file at path against content.
+func compare(path, content string) (State, error) {
+	existing, err := Afs.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Missing, nil
+	}
+	if err != nil {
+		return Clean, errors.Wrapf(err, "failed to check %s", path)
+	}
+	if string(existing) != content {
+		return Modified, nil
+	}
+	return Clean, nil
+}
+
+// linkStatus checks that path is a symlink to workPath, returning
+// Clean if it is.
+func linkStatus(path, workPath string) (State, error) {
+	lstater, ok := Afs.Fs.(afero.Lstater)
+	if !ok {
+		return Unlinked, nil
+	}
+	stat, _, err := lstater.LstatIfPossible(path)
+	if os.IsNotExist(err) {
+		return Missing, nil
+	}
+	if err != nil {
+		return Clean, errors.Wrapf(err, "failed to check %s", path)
+	}
+	if stat.Mode()&os.ModeSymlink == 0 {
+		return Unlinked, nil
+	}
+	reader, ok := Afs.Fs.(afero.LinkReader)
+	if !ok {
+		return Unlinked, nil
+	}
+	target, err := reader.ReadlinkIfPossible(path)
+	if err != nil {
+		return Clean, errors.Wrapf(err, "failed to check %s", path)
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(path), target)
+	}
+	if filepath.Clean(target) != filepath.Clean(workPath) {
+		return Hijacked, nil
+	}
+	if _, err = Afs.Stat(workPath); os.IsNotExist(err) {
+		return Broken, nil
+	}
+	return Clean, nil
+}
@@ -0,0 +1,122 @@
+package diff
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/RedDocMD/dotted/validate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLines(t *testing.T) {
+	lines := Lines("a\nb\nc\n", "a\nB\nc\nd\n")
+	assert.Equal(t, []Line{
+		{Equal, "a"},
+		{Delete, "b"},
+		{Insert, "B"},
+		{Equal, "c"},
+		{Insert, "d"},
+	}, lines)
+
+	// More distinct lines than fit below the surrogate runes
+	var many []string
+	for i := 0; i < 0xE000; i++ {
+		many = append(many, strconv.Itoa(i))
+	}
+	old := strings.Join(many, "\n") + "\n"
+	lines = Lines(old, old+"end\n")
+	assert.Len(t, lines, 0xE001)
+	assert.Equal(t, Line{Equal, "57343"}, lines[0xDFFF])
+	assert.Equal(t, Line{Insert, "end"}, lines[0xE000])
+}
+
+func TestHunks(t *testing.T) {
+	var old, new []string
+	for i := 1; i <= 20; i++ {
+		line := strings.Repeat("x", i)
+		old = append(old, line)
+		switch i {
+		case 3:
+			new = append(new, "changed")
+		case 17:
+		default:
+			new = append(new, line)
+		}
+	}
+	hunks := Hunks(Lines(strings.Join(old, "\n")+"\n", strings.Join(new, "\n")+"\n"), 3)
+	if !assert.Len(t, hunks, 2) {
+		return
+	}
+	assert.Equal(t, "@@ -1,6 +1,6 @@", hunks[0].Header())
+	assert.Equal(t, []Line{
+		{Equal, "x"},
+		{Equal, "xx"},
+		{Delete, "xxx"},
+		{Insert, "changed"},
+		{Equal, "xxxx"},
+		{Equal, "xxxxx"},
+		{Equal, "xxxxxx"},
+	}, hunks[0].Lines)
+	assert.Equal(t, "@@ -14,7 +14,6 @@", hunks[1].Header())
+	assert.Equal(t, Line{Delete, strings.Repeat("x", 17)}, hunks[1].Lines[3])
+
+	// Changes close together share a hunk
+	hunks = Hunks(Lines("a\nb\nc\nd\ne\nf\n", "A\nb\nc\nd\ne\nF\n"), 2)
+	assert.Len(t, hunks, 1)
+	assert.Equal(t, "@@ -1,6 +1,6 @@", hunks[0].Header())
+	assert.Len(t, Hunks(Lines("a\n", "a\n"), 3), 0)
+}
+
+func TestSemantic(t *testing.T) {
+	old := `
+font:
+  size: 11
+  normal:
+    family: Fira Code
+window:
+  opacity: 0.9
+key_bindings:
+  - { key: V, mods: Control, action: Paste }
+  - { key: C, mods: Control, action: Copy }
+`
+	// Reordered and reformatted, with some values changed
+	new := `
+key_bindings:
+- key: V
+  mods: Control|Shift
+  action: Paste
+- {key: C, mods: Control, action: Copy}
+font:
+  normal: {family: "Fira Code"}
+  size: 12
+  bold: {family: Hack}
+`
+	changes, err := Semantic("alacritty.yml", "", []byte(old), []byte(new))
+	assert.Nil(t, err)
+	var report []string
+	for _, change := range changes {
+		report = append(report, change.String())
+	}
+	assert.Equal(t, []string{
+		`font.bold: {"family":"Hack"}`,
+		"font.size: 11 -> 12",
+		`key_bindings[0].mods: "Control" -> "Control|Shift"`,
+		"window: {\"opacity\":0.9}",
+	}, report)
+	assert.Equal(t, Added, changes[0].Kind)
+	assert.Equal(t, Changed, changes[1].Kind)
+	assert.Equal(t, Removed, changes[3].Kind)
+
+	changes, err = Semantic("settings.json", "", []byte(`{"editor.fontSize": 12, "list": [1]}`),
+		[]byte(`{"list": [1, "2"], "editor.fontSize": 12}`))
+	assert.Nil(t, err)
+	assert.Equal(t, []Change{{Path: "list[1]", Kind: Added, New: "2"}}, changes)
+	changes, _ = Semantic("settings.json", "", []byte(`{"editor.fontSize": 12}`), []byte(`{"editor.fontSize": 13}`))
+	assert.Equal(t, `"editor.fontSize": 12 -> 13`, changes[0].String())
+
+	_, err = Semantic("alacritty.yml", "", []byte("a: 1"), []byte("a: [1"))
+	assert.IsType(t, &validate.SyntaxError{}, err)
+	_, err = Semantic(".tmux.conf", "", []byte("a"), []byte("b"))
+	assert.Equal(t, validate.ErrNoFormat, err)
+}
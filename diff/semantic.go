@@ -0,0 +1,186 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/RedDocMD/dotted/validate"
+)
+
+// Kind is what happened to a value.
+type Kind int
+
+const (
+	Added Kind = iota
+	Removed
+	Changed
+)
+
+func (kind Kind) String() string {
+	switch kind {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	default:
+		return "changed"
+	}
+}
+
+// Change is a value which differs between two versions of a
+// structured file. Path is made of keys and list indices, such as
+// keyboard.bindings[2].key, and is empty for the whole document.
+type Change struct {
+	Path string
+	Kind Kind
+	Old  interface{} // Unset if Added
+	New  interface{} // Unset if Removed
+}
+
+func (change Change) String() string {
+	path := change.Path
+	if len(path) == 0 {
+		path = "."
+	}
+	switch change.Kind {
+	case Added:
+		return fmt.Sprintf("%s: %s", path, formatValue(change.New))
+	case Removed:
+		return fmt.Sprintf("%s: %s", path, formatValue(change.Old))
+	default:
+		return fmt.Sprintf("%s: %s -> %s", path, formatValue(change.Old), formatValue(change.New))
+	}
+}
+
+// Semantic parses old and new, which are versions of the file at path,
+// by format or the extension of path as in validate.Decode, and lists
+// the values which differ. Keys are compared regardless of order and
+// formatting.
+func Semantic(path, format string, old, new []byte) ([]Change, error) {
+	oldValue, err := validate.Decode(path, format, old)
+	if err != nil {
+		return nil, err
+	}
+	newValue, err := validate.Decode(path, format, new)
+	if err != nil {
+		return nil, err
+	}
+	return Values(oldValue, newValue), nil
+}
+
+// Values lists the differences between old and new, which are made
+// of maps, lists and scalars as decoded by validate.Decode.
+func Values(old, new interface{}) []Change {
+	var changes []Change
+	compare("", normalize(old), normalize(new), &changes)
+	return changes
+}
+
+func compare(path string, old, new interface{}, changes *[]Change) {
+	oldMap, oldIsMap := old.(map[string]interface{})
+	newMap, newIsMap := new.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		for _, key := range unionKeys(oldMap, newMap) {
+			oldItem, inOld := oldMap[key]
+			newItem, inNew := newMap[key]
+			keyPath := joinKey(path, key)
+			switch {
+			case !inOld:
+				*changes = append(*changes, Change{Path: keyPath, Kind: Added, New: newItem})
+			case !inNew:
+				*changes = append(*changes, Change{Path: keyPath, Kind: Removed, Old: oldItem})
+			default:
+				compare(keyPath, oldItem, newItem, changes)
+			}
+		}
+		return
+	}
+	oldList, oldIsList := old.([]interface{})
+	newList, newIsList := new.([]interface{})
+	if oldIsList && newIsList {
+		for i := 0; i < len(oldList) || i < len(newList); i++ {
+			indexPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(oldList):
+				*changes = append(*changes, Change{Path: indexPath, Kind: Added, New: newList[i]})
+			case i >= len(newList):
+				*changes = append(*changes, Change{Path: indexPath, Kind: Removed, Old: oldList[i]})
+			default:
+				compare(indexPath, oldList[i], newList[i], changes)
+			}
+		}
+		return
+	}
+	if !reflect.DeepEqual(old, new) {
+		*changes = append(*changes, Change{Path: path, Kind: Changed, Old: old, New: new})
+	}
+}
+
+func unionKeys(first, second map[string]interface{}) []string {
+	var keys []string
+	for key := range first {
+		keys = append(keys, key)
+	}
+	for key := range second {
+		if _, ok := first[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var plainKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// joinKey adds key to path, quoting keys which are not plain words,
+// such as "editor.fontSize" in the settings of VS Code.
+func joinKey(path, key string) string {
+	if !plainKey.MatchString(key) {
+		key = strconv.Quote(key)
+	}
+	if len(path) == 0 {
+		return key
+	}
+	return path + "." + key
+}
+
+// normalize turns the maps with keys of any type, which YAML decodes
+// when some key is not a string, into maps with string keys.
+func normalize(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		normalized := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			normalized[fmt.Sprint(key)] = normalize(item)
+		}
+		return normalized
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			normalized[key] = normalize(item)
+		}
+		return normalized
+	case []interface{}:
+		normalized := make([]interface{}, len(value))
+		for i, item := range value {
+			normalized[i] = normalize(item)
+		}
+		return normalized
+	default:
+		return value
+	}
+}
+
+// formatValue writes value as JSON, which quotes strings so that "11"
+// and 11 can be told apart.
+func formatValue(value interface{}) string {
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(buf)
+}
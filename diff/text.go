@@ -0,0 +1,157 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// Op is what happened to a line.
+type Op int
+
+const (
+	Equal Op = iota
+	Insert
+	Delete
+)
+
+// Line is a line of a text diff, without its newline.
+type Line struct {
+	Op   Op
+	Text string
+}
+
+func (line Line) String() string {
+	switch line.Op {
+	case Insert:
+		return "+" + line.Text
+	case Delete:
+		return "-" + line.Text
+	default:
+		return " " + line.Text
+	}
+}
+
+const surrogateMin, surrogateMax = 0xD800, 0xDFFF
+
+// Lines compares old and new line by line.
+func Lines(old, new string) []Line {
+	// Each distinct line becomes one rune, so that the character diff
+	// of the runes is a line diff
+	var lineArray []string
+	lineRunes := make(map[string]rune)
+	toRunes := func(text string) []rune {
+		var runes []rune
+		for _, line := range strings.SplitAfter(text, "\n") {
+			if len(line) == 0 {
+				continue
+			}
+			r, ok := lineRunes[line]
+			if !ok {
+				r = rune(len(lineArray))
+				if r >= surrogateMin {
+					// Surrogates do not survive being made a string
+					r += surrogateMax - surrogateMin + 1
+				}
+				lineRunes[line] = r
+				lineArray = append(lineArray, line)
+			}
+			runes = append(runes, r)
+		}
+		return runes
+	}
+	oldRunes, newRunes := toRunes(old), toRunes(new)
+	dmp := diffmatchpatch.New()
+	var lines []Line
+	for _, diff := range dmp.DiffMainRunes(oldRunes, newRunes, false) {
+		op := Equal
+		switch diff.Type {
+		case diffmatchpatch.DiffInsert:
+			op = Insert
+		case diffmatchpatch.DiffDelete:
+			op = Delete
+		}
+		for _, r := range diff.Text {
+			if r > surrogateMax {
+				r -= surrogateMax - surrogateMin + 1
+			}
+			lines = append(lines, Line{Op: op, Text: strings.TrimSuffix(lineArray[r], "\n")})
+		}
+	}
+	return lines
+}
+
+// Hunk is a run of changed lines with the equal lines around them.
+// Starts count from 1.
+type Hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	Lines              []Line
+}
+
+func (hunk Hunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", hunk.OldStart, hunk.OldLines, hunk.NewStart, hunk.NewLines)
+}
+
+// Hunks groups the changes in lines with up to context equal lines
+// around each, as in a unified diff.
+func Hunks(lines []Line, context int) []Hunk {
+	var hunks []Hunk
+	for i := 0; i < len(lines); {
+		if lines[i].Op == Equal {
+			i++
+			continue
+		}
+		start := max(i-context, 0)
+		// Extend the hunk while the next change is close enough for
+		// the context around both to touch
+		end := i
+		for j := i; j < len(lines) && j <= end+2*context+1; j++ {
+			if lines[j].Op != Equal {
+				end = j
+			}
+		}
+		stop := min(end+context+1, len(lines))
+		hunks = append(hunks, newHunk(lines, start, stop))
+		i = end + 1
+	}
+	return hunks
+}
+
+// newHunk makes a hunk of lines[start:stop].
+func newHunk(lines []Line, start, stop int) Hunk {
+	hunk := Hunk{OldStart: 1, NewStart: 1}
+	for _, line := range lines[:start] {
+		if line.Op != Insert {
+			hunk.OldStart += 1
+		}
+		if line.Op != Delete {
+			hunk.NewStart += 1
+		}
+	}
+	for _, line := range lines[start:stop] {
+		hunk.Lines = append(hunk.Lines, line)
+		if line.Op != Insert {
+			hunk.OldLines += 1
+		}
+		if line.Op != Delete {
+			hunk.NewLines += 1
+		}
+	}
+	return hunk
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
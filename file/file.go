@@ -7,15 +7,24 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/RedDocMD/dotted/crypt"
 	"github.com/RedDocMD/dotted/fs"
+	"github.com/RedDocMD/dotted/render"
 	"github.com/pkg/errors"
 )
 
 var Fs = fs.OsFs
 var Afs = fs.OsAfs
 
+// Encryption is the cipher used for dot-files marked as encrypted.
+// It is nil when no key has been configured.
+var Encryption crypt.Cipher
+
 type DotFile struct {
 	path           string
 	mnemonic       string
@@ -23,6 +32,13 @@ type DotFile struct {
 	currentHistory *HistoryNode
 	hasHistory     bool
 	content        *string // RI: hasHistory ^ (content != nil) == 1
+	encrypted      bool
+	workPath       string // Empty unless deployed as a symlink or a template
+	template       bool
+	// History kept while the file is without history, so that it
+	// can be restored
+	frozenRoot    *HistoryNode
+	frozenCurrent *HistoryNode
 }
 
 func (file *DotFile) Mnemonic() string {
@@ -33,6 +49,64 @@ func (file *DotFile) HasHistory() bool {
 	return file.hasHistory
 }
 
+func (file *DotFile) IsEncrypted() bool {
+	return file.encrypted
+}
+
+// SetEncrypted sets whether the file is encrypted when it is next
+// saved to disk.
+func (file *DotFile) SetEncrypted(encrypted bool) {
+	file.encrypted = encrypted
+}
+
+// WorkPath is where the content of the file is read from when
+// committing. It is the path of the file, unless the file is
+// deployed as a symlink to a copy in the work tree of the store,
+// or rendered from the template at WorkPath.
+func (file *DotFile) WorkPath() string {
+	if len(file.workPath) == 0 {
+		return file.path
+	}
+	return file.workPath
+}
+
+// SetWorkPath makes the file a symlink to workPath when deployed,
+// or a plain copy if workPath is empty.
+func (file *DotFile) SetWorkPath(workPath string) {
+	file.workPath = workPath
+}
+
+func (file *DotFile) IsSymlinked() bool {
+	return len(file.workPath) != 0 && !file.template
+}
+
+// SetTemplate makes the stored content of the file a template, whose
+// source is at sourcePath, which is rendered to the path of the file.
+func (file *DotFile) SetTemplate(sourcePath string) {
+	file.workPath = sourcePath
+	file.template = true
+}
+
+func (file *DotFile) IsTemplate() bool {
+	return file.template
+}
+
+// Rendered returns what should be at the path of the file, which is
+// the rendered content for templates and the content otherwise.
+func (file *DotFile) Rendered() (string, error) {
+	if !file.template {
+		return file.Content(), nil
+	}
+	rendered, err := render.Render(filepath.Base(file.workPath), file.Content())
+	if err != nil {
+		return "", errors.WithMessagef(err, "failed to render %s", file.path)
+	}
+	return rendered, nil
+}
+
+// RemoveHistory makes the file a file without history, with the
+// content of the current node. The history is frozen rather than
+// dropped, and InitHistory brings it back.
 func (file *DotFile) RemoveHistory() {
 	if !file.hasHistory {
 		fmt.Fprintf(os.Stderr, "%s does not have a history, cannot remove it.\n", file.path)
@@ -41,22 +115,57 @@ func (file *DotFile) RemoveHistory() {
 	currentContent := file.currentHistory.Content()
 	file.content = &currentContent
 	file.hasHistory = false
+	file.frozenRoot = file.historyRoot
+	file.frozenCurrent = file.currentHistory
 	file.currentHistory = nil
 	file.historyRoot = nil
 }
 
+// InitHistory makes the file a file with history. A frozen history
+// is restored, with the content as a new node if it changed while
+// the file was without history, and a new history is started
+// otherwise.
 func (file *DotFile) InitHistory() {
 	if file.hasHistory {
 		fmt.Fprintf(os.Stderr, "%s already has a history, cannot init it.\n", file.path)
 		os.Exit(1)
 	}
-	historyRoot := NewHistory(*file.content, currentTime())
+	if file.frozenRoot != nil {
+		file.historyRoot = file.frozenRoot
+		file.currentHistory = file.frozenCurrent
+		if node := file.frozenCurrent.AddCommit(*file.content, currentTime()); node != nil {
+			file.currentHistory = node
+		}
+		file.frozenRoot = nil
+		file.frozenCurrent = nil
+	} else {
+		historyRoot := NewHistory(*file.content, currentTime())
+		file.historyRoot = historyRoot
+		file.currentHistory = historyRoot
+	}
 	file.hasHistory = true
-	file.historyRoot = historyRoot
-	file.currentHistory = historyRoot
 	file.content = nil
 }
 
+// FrozenHistory returns the root of the history frozen when the
+// history of the file was removed, or nil if there is none.
+func (file *DotFile) FrozenHistory() *HistoryNode {
+	return file.frozenRoot
+}
+
+// FrozenCurrent returns the node which was current when the history
+// was frozen, or nil if there is none.
+func (file *DotFile) FrozenCurrent() *HistoryNode {
+	return file.frozenCurrent
+}
+
+// DropFrozenHistory permanently deletes the frozen history once the
+// file is next saved.
+func (file *DotFile) DropFrozenHistory() {
+	file.frozenRoot = nil
+	file.frozenCurrent = nil
+}
+
 func currentTime() time.Time {
 	timeNow := time.Now()
 	timeNowString := timeNow.Format(time.UnixDate)
@@ -76,7 +185,15 @@ func NewDotFile(path, mnemonic string, hasHistory bool) (*DotFile, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create dot file")
 	}
-	content := string(buf)
+	return NewDotFileWithContent(path, mnemonic, string(buf), hasHistory)
+}
+
+// NewDotFileWithContent is like NewDotFile, with content used
+// instead of what is at path.
+func NewDotFileWithContent(path, mnemonic, content string, hasHistory bool) (*DotFile, error) {
+	if !Fs.IsAbs(path) {
+		return nil, fmt.Errorf("failed to create dot file: %s is not absolute path", path)
+	}
 	if !hasHistory {
 		dotFile := &DotFile{
 			path:           path,
@@ -100,6 +217,26 @@ func NewDotFile(path, mnemonic string, hasHistory bool) (*DotFile, error) {
 	return dotFile, nil
 }
 
+// NewDotFileFromHistory creates a dot-file with an existing history,
+// such as one imported from elsewhere, whose current node is current.
+func NewDotFileFromHistory(path, mnemonic string, root, current *HistoryNode) (*DotFile, error) {
+	if !Fs.IsAbs(path) {
+		return nil, fmt.Errorf("failed to create dot file: %s is not absolute path", path)
+	}
+	if root.parent != nil || !root.IsAncestorOf(current) {
+		return nil, fmt.Errorf("failed to create dot file: current node not in history")
+	}
+	dotFile := &DotFile{
+		path:           path,
+		mnemonic:       mnemonic,
+		historyRoot:    root,
+		currentHistory: current,
+		hasHistory:     true,
+		content:        nil,
+	}
+	return dotFile, nil
+}
+
 func (file *DotFile) RelativePath() string {
 	homedir := Fs.UserHomeDir()
 	path := file.path[len(homedir)+1:]
@@ -116,11 +253,20 @@ func (file *DotFile) AddCommit() (bool, error) {
 	if !file.hasHistory {
 		return false, fmt.Errorf("failed to create commit: file without history")
 	}
-	buf, err := Afs.ReadFile(file.path)
+	buf, err := Afs.ReadFile(file.WorkPath())
 	if err != nil {
 		return false, errors.Wrap(err, "failed to create commit")
 	}
-	node := file.currentHistory.AddCommit(string(buf), currentTime())
+	return file.CommitContent(string(buf))
+}
+
+// CommitContent adds a commit with content as a child of the
+// current node and makes it the current node.
+func (file *DotFile) CommitContent(content string) (bool, error) {
+	if !file.hasHistory {
+		return false, fmt.Errorf("failed to create commit: file without history")
+	}
+	node := file.currentHistory.AddCommit(content, currentTime())
 	if node == nil {
 		return false, nil
 	} else {
@@ -129,11 +275,160 @@ func (file *DotFile) AddCommit() (bool, error) {
 	}
 }
 
+// AddVersion records content as a new child of the current node,
+// without making it current. It returns nil if some node in the
+// history already has this content.
+func (file *DotFile) AddVersion(content string) (*HistoryNode, error) {
+	if !file.hasHistory {
+		return nil, fmt.Errorf("failed to add version: file without history")
+	}
+	if file.historyRoot.NodeWithChecksum(sha1.Sum([]byte(content))) != nil {
+		return nil, nil
+	}
+	return file.currentHistory.AddCommit(content, currentTime()), nil
+}
+
+// MergeResult describes the outcome of merging the history of
+// a copy of a dot-file into the original.
+type MergeResult struct {
+	Added int
+	// Tip of the merged copy, which is a separate branch unless
+	// it is an ancestor of the current node.
+	OtherTip *HistoryNode
+	// Set when the current node is an ancestor of OtherTip, so that
+	// checking it out brings file up to date
+	Behind bool
+	// Set when neither of the current node and OtherTip is an
+	// ancestor of the other, so that file has tips to merge
+	Diverged bool
+	// Tags of the merged copy which file has on other commits
+	TagClashes []TagClash
+}
+
+// TagClash is a tag which the merged copy had on a different commit
+// than the original. The tag is kept on the commit of the original.
+type TagClash struct {
+	Tag     string
+	Kept    *HistoryNode
+	Dropped *HistoryNode
+}
+
+// Merge adds the nodes in the history of other, a copy of file
+// from elsewhere, which are missing from the history of file. The
+// current node of file is left as it is, and so are its tags.
+func (file *DotFile) Merge(other *DotFile) (MergeResult, error) {
+	var result MergeResult
+	if !file.hasHistory || !other.hasHistory {
+		return result, fmt.Errorf("failed to merge %s: file without history", file.path)
+	}
+	otherTip := other.currentHistory.uuid.String()
+	tagged := make(map[string][]*HistoryNode)
+	file.historyRoot.taggedNodes(tagged)
+	added, err := file.historyRoot.Merge(other.historyRoot)
+	if err != nil {
+		return result, errors.WithMessagef(err, "failed to merge %s", file.path)
+	}
+	result.Added = added
+	result.TagClashes = file.keepTags(tagged)
+	result.OtherTip = file.historyRoot.NodeWithUUID(otherTip)
+	if !result.OtherTip.IsAncestorOf(file.currentHistory) {
+		result.Behind = file.currentHistory.IsAncestorOf(result.OtherTip)
+		result.Diverged = !result.Behind
+	}
+	return result, nil
+}
+
+// keepTags removes the tags which merging added to other nodes than
+// those which had them in tagged, and returns the clashes.
+func (file *DotFile) keepTags(tagged map[string][]*HistoryNode) []TagClash {
+	merged := make(map[string][]*HistoryNode)
+	file.historyRoot.taggedNodes(merged)
+	tags := make([]string, 0, len(merged))
+	for tag := range merged {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	var clashes []TagClash
+	for _, tag := range tags {
+		if len(tagged[tag]) == 0 {
+			continue
+		}
+		kept := tagged[tag][0]
+		for _, node := range merged[tag] {
+			if node != kept {
+				node.removeTag(tag)
+				clashes = append(clashes, TagClash{Tag: tag, Kept: kept, Dropped: node})
+			}
+		}
+	}
+	return clashes
+}
+
+// Checkout makes node, which must be in the history of file, the
+// current node. The file on disk is left as it is.
+func (file *DotFile) Checkout(node *HistoryNode) error {
+	if !file.hasHistory {
+		return fmt.Errorf("failed to check out %s: file without history", file.path)
+	}
+	if !file.historyRoot.IsAncestorOf(node) {
+		return fmt.Errorf("failed to check out %s: commit %s is not in its history", file.path, node.UUID())
+	}
+	file.currentHistory = node
+	return nil
+}
+
+// Tag names node, which must be in the history of file, with tag. A
+// tag names one commit, so it is moved from any other commit.
+func (file *DotFile) Tag(node *HistoryNode, tag string) error {
+	if !file.hasHistory {
+		return fmt.Errorf("failed to tag %s: file without history", file.path)
+	}
+	if len(tag) == 0 || strings.ContainsAny(tag, " \t\n") {
+		return fmt.Errorf("failed to tag %s: invalid tag %q", file.path, tag)
+	}
+	if !file.historyRoot.IsAncestorOf(node) {
+		return fmt.Errorf("failed to tag %s: commit %s is not in its history", file.path, node.UUID())
+	}
+	file.Untag(tag)
+	node.addTag(tag)
+	return nil
+}
+
+// Untag removes tag from the history of file, reporting whether any
+// commit had it.
+func (file *DotFile) Untag(tag string) bool {
+	if !file.hasHistory {
+		return false
+	}
+	removed := false
+	for node := file.historyRoot.NodeWithTag(tag); node != nil; node = file.historyRoot.NodeWithTag(tag) {
+		removed = node.removeTag(tag) || removed
+	}
+	return removed
+}
+
+func (file *DotFile) CurrentHistory() *HistoryNode {
+	return file.currentHistory
+}
+
+func (file *DotFile) HistoryRoot() *HistoryNode {
+	return file.historyRoot
+}
+
+// Content returns the content at the current node, or the
+// stored content for files without history.
+func (file *DotFile) Content() string {
+	if file.hasHistory {
+		return file.currentHistory.Content()
+	}
+	return *file.content
+}
+
 func (file *DotFile) UpdateContent() (bool, error) {
 	if file.hasHistory {
 		return false, fmt.Errorf("failed to update content: file has history")
 	}
-	buf, err := Afs.ReadFile(file.path)
+	buf, err := Afs.ReadFile(file.WorkPath())
 	if err != nil {
 		return false, errors.Wrap(err, "failed to update content")
 	}
@@ -143,10 +438,22 @@ func (file *DotFile) UpdateContent() (bool, error) {
 	return changed, nil
 }
 
+// IsModified reports whether the content at the work path differs
+// from the content of the current version.
+func (file *DotFile) IsModified() (bool, error) {
+	buf, err := Afs.ReadFile(file.WorkPath())
+	if err != nil {
+		return false, errors.Wrap(err, "failed to read file")
+	}
+	return string(buf) != file.Content(), nil
+}
+
 type jsonDotFileMetadata struct {
 	Mnemonic       string
 	HasHistory     bool
 	CurrentHistory string // UUID of node
+	Encrypted      bool
+	FrozenHistory  string `json:",omitempty"` // UUID of the frozen current node
 }
 
 func (file *DotFile) MetadataToJSON() []byte {
@@ -158,6 +465,10 @@ func (file *DotFile) MetadataToJSON() []byte {
 		Mnemonic:       file.mnemonic,
 		HasHistory:     file.hasHistory,
 		CurrentHistory: currentHistory,
+		Encrypted:      file.encrypted,
+	}
+	if file.frozenCurrent != nil {
+		jsonFile.FrozenHistory = file.frozenCurrent.uuid.String()
 	}
 	bytes, err := json.Marshal(jsonFile)
 	if err != nil {
@@ -175,7 +486,10 @@ func (file *DotFile) SaveToDisk(basePath string) error {
 			return errors.Wrap(err, "failed to save dot file to disk")
 		}
 		defer historyFile.Close()
-		historyData := file.historyRoot.ToJSON()
+		historyData, err := file.seal(file.historyRoot.ToJSON())
+		if err != nil {
+			return errors.Wrap(err, "failed to save dot file to disk")
+		}
 		historyDataBuf := bytes.NewBuffer(historyData)
 		_, err = io.Copy(historyFile, historyDataBuf)
 		if err != nil {
@@ -183,19 +497,27 @@ func (file *DotFile) SaveToDisk(basePath string) error {
 		}
 	}
 
+	if err := file.saveFrozenHistory(basePath); err != nil {
+		return errors.Wrap(err, "failed to save dot file to disk")
+	}
+
 	var content string
 	if file.hasHistory {
 		content = *file.historyRoot.content
 	} else {
 		content = *file.content
 	}
+	contentData, err := file.seal([]byte(content))
+	if err != nil {
+		return errors.Wrap(err, "failed to save dot file to disk")
+	}
 	contentFilePath := Fs.Join(basePath, "content")
 	contentFile, err := Afs.Create(contentFilePath)
 	if err != nil {
 		return errors.Wrap(err, "failed to save dot file to disk")
 	}
 	defer contentFile.Close()
-	_, err = contentFile.WriteString(content)
+	_, err = contentFile.Write(contentData)
 	if err != nil {
 		return errors.Wrap(err, "failed to save dot file to disk")
 	}
@@ -215,7 +537,85 @@ func (file *DotFile) SaveToDisk(basePath string) error {
 	return nil
 }
 
+// Files holding the frozen history and the content of its root
+const (
+	frozenHistoryFile = "frozen-history"
+	frozenContentFile = "frozen-content"
+)
+
+func (file *DotFile) saveFrozenHistory(basePath string) error {
+	historyPath := Fs.Join(basePath, frozenHistoryFile)
+	contentPath := Fs.Join(basePath, frozenContentFile)
+	if file.frozenRoot == nil {
+		for _, path := range []string{historyPath, contentPath} {
+			if err := Afs.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		return nil
+	}
+	historyData, err := file.seal(file.frozenRoot.ToJSON())
+	if err != nil {
+		return err
+	}
+	contentData, err := file.seal([]byte(*file.frozenRoot.content))
+	if err != nil {
+		return err
+	}
+	if err = Afs.WriteFile(historyPath, historyData, 0644); err != nil {
+		return err
+	}
+	return Afs.WriteFile(contentPath, contentData, 0644)
+}
+
+func loadFrozenHistory(basePath string, metadata jsonDotFileMetadata, dotFilePath string) (*HistoryNode, *HistoryNode, error) {
+	historyData, err := Afs.ReadFile(Fs.Join(basePath, frozenHistoryFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	if historyData, err = unseal(historyData, metadata.Encrypted, dotFilePath); err != nil {
+		return nil, nil, err
+	}
+	contentData, err := Afs.ReadFile(Fs.Join(basePath, frozenContentFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	if contentData, err = unseal(contentData, metadata.Encrypted, dotFilePath); err != nil {
+		return nil, nil, err
+	}
+	root, err := FromJSON(historyData, string(contentData))
+	if err != nil {
+		return nil, nil, err
+	}
+	current := root.NodeWithUUID(metadata.FrozenHistory)
+	if current == nil {
+		return nil, nil, fmt.Errorf("%s not found as frozen current history", metadata.FrozenHistory)
+	}
+	return root, current, nil
+}
+
 var BasePathNotFound = errors.New("base path directory not found")
+var KeyNotFound = errors.New("encryption key not configured")
+
+func (file *DotFile) seal(data []byte) ([]byte, error) {
+	if !file.encrypted {
+		return data, nil
+	}
+	if Encryption == nil {
+		return nil, errors.Wrapf(KeyNotFound, "%s is encrypted", file.path)
+	}
+	return Encryption.Encrypt(data)
+}
+
+func unseal(data []byte, encrypted bool, dotFilePath string) ([]byte, error) {
+	if !encrypted {
+		return data, nil
+	}
+	if Encryption == nil {
+		return nil, errors.Wrapf(KeyNotFound, "%s is encrypted", dotFilePath)
+	}
+	return Encryption.Decrypt(data)
+}
 
 func LoadDotFileFromDisk(basePath, dotFilePath string) (*DotFile, error) {
 	if !Fs.IsAbs(dotFilePath) {
@@ -242,6 +642,10 @@ func LoadDotFileFromDisk(basePath, dotFilePath string) (*DotFile, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, fmt.Sprintf("failed to read dot file from disk: %s", basePath))
 	}
+	contentBytes, err = unseal(contentBytes, metadata.Encrypted, dotFilePath)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to read dot file from disk: %s", basePath))
+	}
 	content := string(contentBytes)
 	var historyRoot, currentHistory *HistoryNode
 	var dotFileContent *string
@@ -251,6 +655,10 @@ func LoadDotFileFromDisk(basePath, dotFilePath string) (*DotFile, error) {
 		if err != nil {
 			return nil, errors.Wrap(err, fmt.Sprintf("failed to read dot file from disk: %s", basePath))
 		}
+		historyFileBytes, err = unseal(historyFileBytes, metadata.Encrypted, dotFilePath)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to read dot file from disk: %s", basePath))
+		}
 		historyRoot, err = FromJSON(historyFileBytes, content)
 		if err != nil {
 			return nil, errors.Wrap(err, fmt.Sprintf("failed to read dot file from disk: %s", basePath))
@@ -262,6 +670,13 @@ func LoadDotFileFromDisk(basePath, dotFilePath string) (*DotFile, error) {
 	} else {
 		dotFileContent = &content
 	}
+	var frozenRoot, frozenCurrent *HistoryNode
+	if !metadata.HasHistory && len(metadata.FrozenHistory) != 0 {
+		frozenRoot, frozenCurrent, err = loadFrozenHistory(basePath, metadata, dotFilePath)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to read dot file from disk: %s", basePath))
+		}
+	}
 	dotFile := &DotFile{
 		path:           dotFilePath,
 		mnemonic:       metadata.Mnemonic,
@@ -269,6 +684,9 @@ func LoadDotFileFromDisk(basePath, dotFilePath string) (*DotFile, error) {
 		currentHistory: currentHistory,
 		hasHistory:     metadata.HasHistory,
 		content:        dotFileContent,
+		encrypted:      metadata.Encrypted,
+		frozenRoot:     frozenRoot,
+		frozenCurrent:  frozenCurrent,
 	}
 	return dotFile, nil
 }
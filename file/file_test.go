@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/RedDocMD/dotted/crypt"
 	"github.com/RedDocMD/dotted/fs"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -75,8 +76,11 @@ func (suite *DotFileTestSuite) TestCommitDotFile() {
 
 	dotFileWithHistory, _ := NewDotFile(suite.firstPath, "test", true)
 	dotFileWithoutHistory, _ := NewDotFile(suite.firstPath, "test", false)
+	modified, err := dotFileWithHistory.IsModified()
+	assert.Nil(err)
+	assert.False(modified)
 
-	err := Afs.Remove(suite.firstPath)
+	err = Afs.Remove(suite.firstPath)
 	if err != nil {
 		suite.T().Fatal(err)
 	}
@@ -85,9 +89,13 @@ func (suite *DotFileTestSuite) TestCommitDotFile() {
 		suite.T().Fatal(err)
 	}
 
+	modified, _ = dotFileWithHistory.IsModified()
+	assert.True(modified)
 	changed, err := dotFileWithHistory.AddCommit()
 	assert.Equal(err, nil)
 	assert.True(changed)
+	modified, _ = dotFileWithHistory.IsModified()
+	assert.False(modified)
 
 	changed, err = dotFileWithoutHistory.AddCommit()
 	assert.Error(err, "failed to create commit: file without history")
@@ -170,3 +178,126 @@ func (suite *DotFileTestSuite) TestDotFileStoreAndLoad() {
 	assert.Equal(err, nil)
 	assert.Equal(dotFile, restoredDotFile)
 }
+
+func (suite *DotFileTestSuite) TestEncryptedDotFileStoreAndLoad() {
+	assert := assert.New(suite.T())
+	workFactor := crypt.ScryptWorkFactor
+	crypt.ScryptWorkFactor = 10
+	defer func() {
+		crypt.ScryptWorkFactor = workFactor
+		Encryption = nil
+	}()
+
+	dotFile, _ := NewDotFile(suite.firstPath, "first", true)
+	dotFile.SetEncrypted(true)
+	err := dotFile.SaveToDisk(suite.storePath)
+	assert.ErrorIs(err, KeyNotFound)
+
+	Encryption, err = crypt.NewPassphraseCipher("passphrase")
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	err = dotFile.SaveToDisk(suite.storePath)
+	assert.Nil(err)
+	storedContent, _ := Afs.ReadFile(Fs.Join(suite.storePath, "content"))
+	assert.NotContains(string(storedContent), globalFirstFileContent)
+	restoredDotFile, err := LoadDotFileFromDisk(suite.storePath, suite.firstPath)
+	assert.Nil(err)
+	assert.Equal(dotFile, restoredDotFile)
+
+	Encryption = nil
+	_, err = LoadDotFileFromDisk(suite.storePath, suite.firstPath)
+	assert.ErrorIs(err, KeyNotFound)
+}
+
+func (suite *DotFileTestSuite) TestFreezeHistory() {
+	assert := assert.New(suite.T())
+	dotFile, _ := NewDotFile(suite.firstPath, "first", true)
+	root := dotFile.HistoryRoot()
+	Afs.WriteFile(suite.firstPath, []byte(globalSecondFileContent), 0644)
+	dotFile.AddCommit()
+	second := dotFile.CurrentHistory()
+
+	dotFile.RemoveHistory()
+	assert.False(dotFile.HasHistory())
+	assert.Equal(globalSecondFileContent, dotFile.Content())
+	assert.Equal(root, dotFile.FrozenHistory())
+	assert.Equal(second, dotFile.FrozenCurrent())
+
+	err := dotFile.SaveToDisk(suite.storePath)
+	assert.Nil(err)
+	restoredDotFile, err := LoadDotFileFromDisk(suite.storePath, suite.firstPath)
+	assert.Nil(err)
+	assert.Equal(dotFile, restoredDotFile)
+
+	// Switching back restores the tree, continuing from the content
+	// the file has now
+	Afs.WriteFile(suite.firstPath, []byte("Third"), 0644)
+	restoredDotFile.UpdateContent()
+	restoredDotFile.InitHistory()
+	assert.Nil(restoredDotFile.FrozenHistory())
+	assert.Equal(root.UUID(), restoredDotFile.HistoryRoot().UUID())
+	assert.Equal("Third", restoredDotFile.Content())
+	assert.Equal(second.UUID(), restoredDotFile.CurrentHistory().Parent().UUID())
+
+	dotFile.DropFrozenHistory()
+	err = dotFile.SaveToDisk(suite.storePath)
+	assert.Nil(err)
+	exists, _ := Afs.Exists(Fs.Join(suite.storePath, frozenHistoryFile))
+	assert.False(exists)
+	dotFile.InitHistory()
+	assert.Nil(dotFile.HistoryRoot().Parent())
+	assert.Len(dotFile.HistoryRoot().Children(), 0)
+}
+
+func (suite *DotFileTestSuite) TestCheckoutAndTag() {
+	assert := assert.New(suite.T())
+	dotFile, _ := NewDotFile(suite.firstPath, "first", true)
+	root := dotFile.HistoryRoot()
+	Afs.WriteFile(suite.firstPath, []byte(globalSecondFileContent), 0644)
+	dotFile.AddCommit()
+	second := dotFile.CurrentHistory()
+
+	assert.Nil(dotFile.Checkout(root))
+	assert.Equal(root, dotFile.CurrentHistory())
+	assert.Equal(globalFirstFileContent, dotFile.Content())
+	assert.Error(dotFile.Checkout(NewHistory("other", currentTime())))
+	assert.Equal(root, dotFile.CurrentHistory())
+
+	assert.Nil(dotFile.Tag(root, "stable"))
+	assert.Nil(dotFile.Tag(second, "stable"))
+	assert.Len(root.Tags(), 0)
+	assert.Equal([]string{"stable"}, second.Tags())
+	assert.Error(dotFile.Tag(second, "two words"))
+	assert.True(dotFile.Untag("stable"))
+	assert.False(dotFile.Untag("stable"))
+	assert.Len(second.Tags(), 0)
+
+	withoutHistory, _ := NewDotFile(suite.firstPath, "first", false)
+	assert.Error(withoutHistory.Checkout(root))
+	assert.Error(withoutHistory.Tag(root, "stable"))
+}
+
+func (suite *DotFileTestSuite) TestMergeKeepsLocalTags() {
+	assert := assert.New(suite.T())
+	dotFile, _ := NewDotFile(suite.firstPath, "first", true)
+	root := dotFile.HistoryRoot()
+	Afs.WriteFile(suite.firstPath, []byte(globalSecondFileContent), 0644)
+	dotFile.AddCommit()
+	second := dotFile.CurrentHistory()
+
+	// The copy tags the root with a tag which file has on second
+	remoteRoot, err := FromJSON(root.ToJSON(), globalFirstFileContent)
+	assert.Nil(err)
+	remote := &DotFile{path: dotFile.path, hasHistory: true, historyRoot: remoteRoot, currentHistory: remoteRoot}
+	assert.Nil(remote.Tag(remoteRoot, "stable"))
+	assert.Nil(remote.Tag(remoteRoot.NodeWithUUID(second.UUID()), "old"))
+	assert.Nil(dotFile.Tag(second, "stable"))
+
+	result, err := dotFile.Merge(remote)
+	assert.Nil(err)
+	assert.Equal([]TagClash{{Tag: "stable", Kept: second, Dropped: root}}, result.TagClashes)
+	assert.Len(root.Tags(), 0)
+	assert.Equal([]string{"stable", "old"}, second.Tags())
+	assert.Equal(second, root.NodeWithTag("stable"))
+}
@@ -22,6 +22,8 @@ type HistoryNode struct {
 	children  []*HistoryNode
 	uuid      uuid.UUID
 	timestamp time.Time
+	message   string
+	tags      []string
 }
 
 // NewHistory creates a new history tree and returns
@@ -112,6 +114,201 @@ func (node *HistoryNode) NodeWithUUID(uuid string) *HistoryNode {
 	return nil
 }
 
+func (node *HistoryNode) NodeWithChecksum(checksum Sha) *HistoryNode {
+	if node.checksum == checksum {
+		return node
+	}
+	for _, child := range node.children {
+		subNode := child.NodeWithChecksum(checksum)
+		if subNode != nil {
+			return subNode
+		}
+	}
+	return nil
+}
+
+// IsAncestorOf reports whether node lies on the path from the
+// root to other, which includes other itself.
+func (node *HistoryNode) IsAncestorOf(other *HistoryNode) bool {
+	for ptr := other; ptr != nil; ptr = ptr.parent {
+		if ptr == node {
+			return true
+		}
+	}
+	return false
+}
+
+// Leaves returns the nodes without children in the sub-tree
+// rooted at node.
+func (node *HistoryNode) Leaves() []*HistoryNode {
+	if len(node.children) == 0 {
+		return []*HistoryNode{node}
+	}
+	var leaves []*HistoryNode
+	for _, child := range node.children {
+		leaves = append(leaves, child.Leaves()...)
+	}
+	return leaves
+}
+
+func (node *HistoryNode) uuids(set map[uuid.UUID]struct{}) {
+	set[node.uuid] = struct{}{}
+	for _, child := range node.children {
+		child.uuids(set)
+	}
+}
+
+func (node *HistoryNode) size() int {
+	count := 1
+	for _, child := range node.children {
+		count += child.size()
+	}
+	return count
+}
+
+// Merge adds every node of the tree rooted at other which is
+// missing from the tree rooted at history, matching nodes by UUID,
+// and returns the number of nodes added. Both trees must share their
+// root and the nodes they share must have the same content, else
+// neither tree is changed. Nodes are moved out of other, which must
+// not be used afterwards.
+func (history *HistoryNode) Merge(other *HistoryNode) (int, error) {
+	if history.parent != nil || other.parent != nil {
+		return 0, fmt.Errorf("failed to merge histories: expected roots")
+	}
+	if history.uuid != other.uuid {
+		return 0, fmt.Errorf("failed to merge histories: unrelated roots %s and %s", history.uuid, other.uuid)
+	}
+	known := make(map[uuid.UUID]struct{})
+	history.uuids(known)
+	if err := history.conflict(other, known); err != nil {
+		return 0, err
+	}
+	return history.merge(other, known), nil
+}
+
+// conflict checks that other can be merged into history.
+func (history *HistoryNode) conflict(other *HistoryNode, known map[uuid.UUID]struct{}) error {
+	if history.checksum != other.checksum {
+		return fmt.Errorf("failed to merge histories: node %s has different content", history.uuid)
+	}
+	for _, otherChild := range other.children {
+		if child := history.child(otherChild.uuid); child != nil {
+			if err := child.conflict(otherChild, known); err != nil {
+				return err
+			}
+		} else if _, ok := known[otherChild.uuid]; ok {
+			return fmt.Errorf("failed to merge histories: node %s has different parents", otherChild.uuid)
+		}
+	}
+	return nil
+}
+
+func (history *HistoryNode) merge(other *HistoryNode, known map[uuid.UUID]struct{}) int {
+	for _, tag := range other.tags {
+		history.addTag(tag)
+	}
+	added := 0
+	for _, otherChild := range other.children {
+		if child := history.child(otherChild.uuid); child != nil {
+			added += child.merge(otherChild, known)
+			continue
+		}
+		// Patches are relative to the parent, which has the same content
+		otherChild.parent = history
+		history.children = append(history.children, otherChild)
+		otherChild.uuids(known)
+		added += otherChild.size()
+	}
+	return added
+}
+
+func (node *HistoryNode) child(id uuid.UUID) *HistoryNode {
+	for _, child := range node.children {
+		if child.uuid == id {
+			return child
+		}
+	}
+	return nil
+}
+
+func (node *HistoryNode) UUID() string {
+	return node.uuid.String()
+}
+
+func (node *HistoryNode) Parent() *HistoryNode {
+	return node.parent
+}
+
+func (node *HistoryNode) Children() []*HistoryNode {
+	return node.children
+}
+
+func (node *HistoryNode) Timestamp() time.Time {
+	return node.timestamp
+}
+
+// Message returns the description of the commit, which is
+// empty unless one was set.
+func (node *HistoryNode) Message() string {
+	return node.message
+}
+
+func (node *HistoryNode) SetMessage(message string) {
+	node.message = message
+}
+
+// Tags returns the names given to the commit, see DotFile.Tag.
+func (node *HistoryNode) Tags() []string {
+	return node.tags
+}
+
+func (node *HistoryNode) addTag(tag string) {
+	for _, existing := range node.tags {
+		if existing == tag {
+			return
+		}
+	}
+	node.tags = append(node.tags, tag)
+}
+
+func (node *HistoryNode) removeTag(tag string) bool {
+	for i, existing := range node.tags {
+		if existing == tag {
+			node.tags = append(node.tags[:i], node.tags[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// taggedNodes adds the nodes of the sub-tree rooted at node to
+// tagged, under each of their tags.
+func (node *HistoryNode) taggedNodes(tagged map[string][]*HistoryNode) {
+	for _, tag := range node.tags {
+		tagged[tag] = append(tagged[tag], node)
+	}
+	for _, child := range node.children {
+		child.taggedNodes(tagged)
+	}
+}
+
+// NodeWithTag finds the node in the sub-tree rooted at node which has
+// tag, or returns nil.
+func (node *HistoryNode) NodeWithTag(tag string) *HistoryNode {
+	for _, existing := range node.tags {
+		if existing == tag {
+			return node
+		}
+	}
+	for _, child := range node.children {
+		if subNode := child.NodeWithTag(tag); subNode != nil {
+			return subNode
+		}
+	}
+	return nil
+}
+
 type jsonHistoryNode struct {
 	Parent    string
 	Patches   string
@@ -119,6 +316,8 @@ type jsonHistoryNode struct {
 	Children  []string
 	Uuid      string
 	Timestamp string
+	Message   string   `json:",omitempty"`
+	Tags      []string `json:",omitempty"`
 }
 
 func newJsonHistoryNode(node *HistoryNode) jsonHistoryNode {
@@ -143,6 +342,8 @@ func newJsonHistoryNode(node *HistoryNode) jsonHistoryNode {
 		Children:  children,
 		Uuid:      node.uuid.String(),
 		Timestamp: string(timestamp),
+		Message:   node.message,
+		Tags:      node.tags,
 	}
 }
 
@@ -172,6 +373,8 @@ func decodeJsonHistoryNode(node jsonHistoryNode, parent *HistoryNode, content *s
 		checksum:  checksum,
 		uuid:      uuid,
 		timestamp: timestamp,
+		message:   node.Message,
+		tags:      node.Tags,
 	}
 	return newNode, nil
 }
@@ -77,3 +77,50 @@ func TestJsonToTree(t *testing.T) {
 	assert.Equal(err, nil)
 	assert.Equal(tree, decodedTree)
 }
+
+func TestMergeHistories(t *testing.T) {
+	assert := assert.New(t)
+	local := makeTree()
+	remote, err := FromJSON(local.ToJSON(), "hello")
+	assert.Nil(err)
+
+	localTip := local.children[1].AddCommit("hello7", currentTime())
+	remoteTip := remote.children[1].children[1].AddCommit("hello8", currentTime())
+	remote.children[0].addTag("stable")
+	remoteTip.AddCommit("hello9", currentTime())
+
+	added, err := local.Merge(remote)
+	assert.Nil(err)
+	assert.Equal(2, added)
+	assert.Equal(10, local.size())
+	merged := local.NodeWithUUID(remoteTip.UUID())
+	assert.NotNil(merged)
+	assert.Equal("hello8", merged.Content())
+	assert.False(merged.IsAncestorOf(localTip))
+	assert.True(local.children[1].IsAncestorOf(merged))
+	assert.Equal([]string{"stable"}, local.children[0].Tags())
+
+	again, err := FromJSON(local.ToJSON(), "hello")
+	assert.Nil(err)
+	added, err = local.Merge(again)
+	assert.Nil(err)
+	assert.Equal(0, added)
+
+	_, err = local.Merge(NewHistory("hello", currentTime()))
+	assert.NotNil(err)
+}
+
+func TestTagsToJSON(t *testing.T) {
+	assert := assert.New(t)
+	tree := makeTree()
+	tree.children[1].addTag("stable")
+	tree.children[1].addTag("laptop")
+	tree.children[1].addTag("stable")
+
+	restored, err := FromJSON(tree.ToJSON(), "hello")
+	assert.Nil(err)
+	assert.Equal([]string{"stable", "laptop"}, restored.children[1].Tags())
+	assert.Nil(restored.Tags())
+	assert.Equal(restored.children[1], restored.NodeWithTag("laptop"))
+	assert.Nil(restored.NodeWithTag("desktop"))
+}
@@ -46,7 +46,7 @@ func (fs WrappedOsFs) UserHomeDir() string {
 	return dir
 }
 
-func (fs WrappedMockFs) UserHomeDir() string {
+func (fs *WrappedMockFs) UserHomeDir() string {
 	return "/home/dknite"
 }
 
@@ -54,7 +54,7 @@ func (fs WrappedOsFs) Join(components ...string) string {
 	return filepath.Join(components...)
 }
 
-func (fs WrappedMockFs) Join(components ...string) string {
+func (fs *WrappedMockFs) Join(components ...string) string {
 	return path.Join(components...)
 }
 
@@ -62,7 +62,7 @@ func (fs WrappedOsFs) IsAbs(path string) bool {
 	return filepath.IsAbs(path)
 }
 
-func (fs WrappedMockFs) IsAbs(pathstr string) bool {
+func (fs *WrappedMockFs) IsAbs(pathstr string) bool {
 	return path.IsAbs(pathstr)
 }
 
@@ -75,7 +75,7 @@ func (fs WrappedOsFs) Abs(path string) string {
 	return dir
 }
 
-func (fs WrappedMockFs) Abs(path string) string {
+func (fs *WrappedMockFs) Abs(path string) string {
 	if !fs.IsAbs(path) {
 		return fs.Join(fs.UserHomeDir(), path)
 	}
@@ -0,0 +1,207 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/RedDocMD/dotted/file"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Every exported node is kept alive by a ref under this prefix,
+	// named by its UUID, so that exports can be incremental.
+	nodeRefPrefix = "refs/dotted/nodes/"
+	// Every leaf of the history is exported as a branch with
+	// this prefix followed by the start of its UUID.
+	tipBranchPrefix = "tip-"
+	// Trailer recording the node a commit was made from
+	nodeTrailer = "Dotted-Node"
+)
+
+// EncryptedFile is returned when exporting an encrypted file, whose
+// history would be written to the repository in plain text, without
+// asking to include encrypted files.
+var EncryptedFile = errors.New("file is encrypted")
+
+// ExportResult describes a history exported to a repository.
+type ExportResult struct {
+	Exported int // Number of nodes not already in the repository
+	Branches []string
+	Head     string // Branch or commit HEAD points to
+}
+
+// ExportHistory replays the history of dotFile as commits in the bare
+// repository at dir, which is created if needed. Every leaf of the
+// history becomes a branch and HEAD points to the current node.
+// Commits are built only from the history, so exporting again only
+// adds the new nodes and never changes existing commits. Encrypted
+// files are only exported with includeEncrypted.
+func ExportHistory(dotFile *file.DotFile, dir string, includeEncrypted bool) (ExportResult, error) {
+	var result ExportResult
+	if !dotFile.HasHistory() {
+		return result, fmt.Errorf("failed to export %s: file without history", dotFile.Path())
+	}
+	if dotFile.IsEncrypted() && !includeEncrypted {
+		return result, errors.WithMessagef(EncryptedFile, "failed to export %s", dotFile.Path())
+	}
+	var repo *Repo
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		repo, err = Init(dir, true)
+		if err != nil {
+			return result, errors.WithMessagef(err, "failed to export %s", dotFile.Path())
+		}
+	} else {
+		repo = Open(dir)
+	}
+	exporter := &exporter{
+		repo:    repo,
+		path:    filepath.ToSlash(dotFile.RelativePath()),
+		commits: make(map[string]string),
+		index:   filepath.Join(dir, "dotted-index"),
+	}
+	defer os.Remove(exporter.index)
+	if err := exporter.readNodeRefs(); err != nil {
+		return result, errors.WithMessagef(err, "failed to export %s", dotFile.Path())
+	}
+
+	stack := []*file.HistoryNode{dotFile.HistoryRoot()}
+	for len(stack) != 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if _, ok := exporter.commits[node.UUID()]; !ok {
+			if err := exporter.exportNode(node); err != nil {
+				return result, errors.WithMessagef(err, "failed to export %s", dotFile.Path())
+			}
+			result.Exported += 1
+		}
+		stack = append(stack, node.Children()...)
+	}
+
+	branches, err := exporter.updateBranches(dotFile.HistoryRoot().Leaves())
+	if err != nil {
+		return result, errors.WithMessagef(err, "failed to export %s", dotFile.Path())
+	}
+	result.Branches = branches
+	result.Head, err = exporter.updateHead(dotFile.CurrentHistory())
+	if err != nil {
+		return result, errors.WithMessagef(err, "failed to export %s", dotFile.Path())
+	}
+	return result, nil
+}
+
+type exporter struct {
+	repo    *Repo
+	path    string            // Path of the file in each commit
+	commits map[string]string // Commit of each exported node by UUID
+	index   string            // Scratch index to build trees with
+}
+
+func (exporter *exporter) readNodeRefs() error {
+	out, err := exporter.repo.Run("for-each-ref", "--format=%(objectname) %(refname)", nodeRefPrefix)
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			exporter.commits[strings.TrimPrefix(fields[1], nodeRefPrefix)] = fields[0]
+		}
+	}
+	return nil
+}
+
+func (exporter *exporter) exportNode(node *file.HistoryNode) error {
+	blob, err := exporter.repo.RunWithInput(strings.NewReader(node.Content()), "hash-object", "-w", "--stdin")
+	if err != nil {
+		return err
+	}
+	indexEnv := []string{"GIT_INDEX_FILE=" + exporter.index}
+	if _, err = exporter.repo.RunWithEnv(indexEnv, nil, "read-tree", "--empty"); err != nil {
+		return err
+	}
+	cacheInfo := fmt.Sprintf("100644,%s,%s", strings.TrimSpace(blob), exporter.path)
+	if _, err = exporter.repo.RunWithEnv(indexEnv, nil, "update-index", "--add", "--cacheinfo", cacheInfo); err != nil {
+		return err
+	}
+	tree, err := exporter.repo.RunWithEnv(indexEnv, nil, "write-tree")
+	if err != nil {
+		return err
+	}
+
+	args := []string{"commit-tree", strings.TrimSpace(tree)}
+	subject := "Add " + exporter.path
+	if parent := node.Parent(); parent != nil {
+		args = append(args, "-p", exporter.commits[parent.UUID()])
+		subject = "Update " + exporter.path
+	}
+	if len(node.Message()) != 0 {
+		subject = node.Message()
+	}
+	message := fmt.Sprintf("%s\n\n%s: %s\n", subject, nodeTrailer, node.UUID())
+	date := fmt.Sprintf("%d %s", node.Timestamp().Unix(), node.Timestamp().Format("-0700"))
+	commitEnv := []string{
+		"GIT_AUTHOR_NAME=" + DefaultName,
+		"GIT_AUTHOR_EMAIL=" + DefaultEmail,
+		"GIT_AUTHOR_DATE=" + date,
+		"GIT_COMMITTER_NAME=" + DefaultName,
+		"GIT_COMMITTER_EMAIL=" + DefaultEmail,
+		"GIT_COMMITTER_DATE=" + date,
+	}
+	commit, err := exporter.repo.RunWithEnv(commitEnv, strings.NewReader(message), args...)
+	if err != nil {
+		return err
+	}
+	commit = strings.TrimSpace(commit)
+	if _, err = exporter.repo.Run("update-ref", nodeRefPrefix+node.UUID(), commit); err != nil {
+		return err
+	}
+	exporter.commits[node.UUID()] = commit
+	return nil
+}
+
+func tipBranch(node *file.HistoryNode) string {
+	return tipBranchPrefix + node.UUID()[:8]
+}
+
+// updateBranches points a branch at every leaf and deletes the
+// branches of nodes which are no longer leaves.
+func (exporter *exporter) updateBranches(leaves []*file.HistoryNode) ([]string, error) {
+	var branches []string
+	wanted := make(map[string]struct{})
+	for _, leaf := range leaves {
+		branch := tipBranch(leaf)
+		if _, err := exporter.repo.Run("update-ref", "refs/heads/"+branch, exporter.commits[leaf.UUID()]); err != nil {
+			return nil, err
+		}
+		wanted[branch] = struct{}{}
+		branches = append(branches, branch)
+	}
+	out, err := exporter.repo.Run("for-each-ref", "--format=%(refname:strip=2)", "refs/heads/"+tipBranchPrefix+"*")
+	if err != nil {
+		return nil, err
+	}
+	for _, branch := range strings.Fields(out) {
+		if _, ok := wanted[branch]; !ok {
+			if _, err = exporter.repo.Run("update-ref", "-d", "refs/heads/"+branch); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return branches, nil
+}
+
+// updateHead attaches HEAD to the branch of current if it is a leaf
+// and detaches it at the commit of current otherwise.
+func (exporter *exporter) updateHead(current *file.HistoryNode) (string, error) {
+	if len(current.Children()) == 0 {
+		branch := tipBranch(current)
+		_, err := exporter.repo.Run("symbolic-ref", "HEAD", "refs/heads/"+branch)
+		return branch, err
+	}
+	commit := exporter.commits[current.UUID()]
+	_, err := exporter.repo.Run("update-ref", "--no-deref", "HEAD", commit)
+	return commit, err
+}
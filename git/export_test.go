@@ -0,0 +1,115 @@
+package git
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/fs"
+	"github.com/stretchr/testify/suite"
+)
+
+type ExportSuite struct {
+	suite.Suite
+	dotFile *file.DotFile
+	dir     string
+}
+
+func (suite *ExportSuite) SetupSuite() {
+	if _, err := exec.LookPath("git"); err != nil {
+		suite.T().Skip("git not installed")
+	}
+	file.Fs = fs.MockFs
+	file.Afs = fs.MockAfs
+}
+
+func (suite *ExportSuite) TearDownSuite() {
+	file.Fs = fs.OsFs
+	file.Afs = fs.OsAfs
+}
+
+func (suite *ExportSuite) SetupTest() {
+	file.Afs.MkdirAll("/home/dknite/.config/nvim", 0755)
+	suite.commit("set number\n")
+	suite.dotFile, _ = file.NewDotFile("/home/dknite/.config/nvim/init.vim", "nvim", true)
+	suite.commit("set number\nset relativenumber\n")
+	suite.dir = filepath.Join(suite.T().TempDir(), "nvim.git")
+}
+
+func (suite *ExportSuite) TearDownTest() {
+	file.Afs.RemoveAll("/")
+}
+
+func TestExportSuite(t *testing.T) {
+	suite.Run(t, &ExportSuite{})
+}
+
+func (suite *ExportSuite) commit(content string) {
+	file.Afs.WriteFile("/home/dknite/.config/nvim/init.vim", []byte(content), 0644)
+	if suite.dotFile != nil {
+		if _, err := suite.dotFile.AddCommit(); err != nil {
+			suite.T().Fatal(err)
+		}
+	}
+}
+
+func (suite *ExportSuite) run(args ...string) string {
+	out, err := Open(suite.dir).Run(args...)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	return strings.TrimSpace(out)
+}
+
+func (suite *ExportSuite) TestExportIsIncremental() {
+	result, err := ExportHistory(suite.dotFile, suite.dir, false)
+	suite.Nil(err)
+	suite.Equal(2, result.Exported)
+	suite.Len(result.Branches, 1)
+	suite.Equal(result.Branches[0], result.Head)
+	suite.Equal("set number\nset relativenumber", suite.run("show", "HEAD:.config/nvim/init.vim"))
+	suite.Equal("2", suite.run("rev-list", "--count", "HEAD"))
+	timestamp := suite.dotFile.CurrentHistory().Timestamp().Unix()
+	suite.Equal(strconv.FormatInt(timestamp, 10), suite.run("log", "-1", "--format=%at"))
+	firstHead := suite.run("rev-parse", "HEAD")
+
+	result, err = ExportHistory(suite.dotFile, suite.dir, false)
+	suite.Nil(err)
+	suite.Equal(0, result.Exported)
+	suite.Equal(firstHead, suite.run("rev-parse", "HEAD"))
+
+	suite.commit("set number\nset relativenumber\nset mouse=a\n")
+	result, err = ExportHistory(suite.dotFile, suite.dir, false)
+	suite.Nil(err)
+	suite.Equal(1, result.Exported)
+	suite.Equal(firstHead, suite.run("rev-parse", "HEAD~1"))
+	suite.Len(strings.Fields(suite.run("branch", "--format=%(refname:short)")), 1)
+}
+
+func (suite *ExportSuite) TestExportBranches() {
+	root := suite.dotFile.HistoryRoot()
+	root.AddCommit("set nonumber\n", root.Timestamp())
+
+	result, err := ExportHistory(suite.dotFile, suite.dir, false)
+	suite.Nil(err)
+	suite.Equal(3, result.Exported)
+	suite.Len(result.Branches, 2)
+	suite.Equal(tipBranch(suite.dotFile.CurrentHistory()), result.Head)
+	for _, branch := range result.Branches {
+		suite.Equal(suite.run("rev-parse", nodeRefPrefix+root.UUID()), suite.run("rev-parse", branch+"~1"))
+	}
+}
+
+func (suite *ExportSuite) TestExportSkipsEncrypted() {
+	suite.dotFile.SetEncrypted(true)
+	_, err := ExportHistory(suite.dotFile, suite.dir, false)
+	suite.ErrorIs(err, EncryptedFile)
+	suite.NoDirExists(suite.dir)
+
+	result, err := ExportHistory(suite.dotFile, suite.dir, true)
+	suite.Nil(err)
+	suite.Equal(2, result.Exported)
+}
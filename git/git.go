@@ -0,0 +1,136 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Identity used for commits when git has no user configured.
+const (
+	DefaultName  = "dotted"
+	DefaultEmail = "dotted@localhost"
+)
+
+// Repo runs the git executable against the repository at Dir.
+type Repo struct {
+	Dir string
+	Env []string // Extra environment variables, as KEY=value
+}
+
+func Open(dir string) *Repo {
+	return &Repo{Dir: dir}
+}
+
+// Init creates an empty repository at dir, which is bare if bare is set.
+func Init(dir string, bare bool) (*Repo, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrap(err, "failed to init repository")
+	}
+	repo := Open(dir)
+	args := []string{"init", "--quiet"}
+	if bare {
+		args = append(args, "--bare")
+	}
+	if _, err := repo.Run(args...); err != nil {
+		return nil, errors.WithMessage(err, "failed to init repository")
+	}
+	return repo, nil
+}
+
+// IsRepo reports whether dir is the root of a repository with a
+// working tree.
+func IsRepo(dir string) bool {
+	stat, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil && stat.IsDir()
+}
+
+// Run runs git with args and returns its standard output.
+func (repo *Repo) Run(args ...string) (string, error) {
+	return repo.RunWithInput(nil, args...)
+}
+
+// RunWithInput runs git with args, feeding it stdin, and returns
+// its standard output.
+func (repo *Repo) RunWithInput(stdin io.Reader, args ...string) (string, error) {
+	return repo.RunWithEnv(nil, stdin, args...)
+}
+
+// RunWithEnv is like RunWithInput, with env added to the
+// environment of this run only.
+func (repo *Repo) RunWithEnv(env []string, stdin io.Reader, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repo.Dir
+	cmd.Env = append(append(os.Environ(), repo.Env...), env...)
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if len(message) == 0 {
+			message = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", subcommand(args), message)
+	}
+	return stdout.String(), nil
+}
+
+// subcommand finds the git command in args, after the options of git
+// itself such as -c name=value.
+func subcommand(args []string) string {
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-c" || args[i] == "-C":
+			i++
+		case !strings.HasPrefix(args[i], "-"):
+			return args[i]
+		}
+	}
+	return strings.Join(args, " ")
+}
+
+// RevParse returns the object name of rev, or false if it does not exist.
+func (repo *Repo) RevParse(rev string) (string, bool) {
+	out, err := repo.Run("rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(out), true
+}
+
+// HasIdentity reports whether git has a user to author commits with.
+func (repo *Repo) HasIdentity() bool {
+	_, nameErr := repo.Run("config", "user.name")
+	_, emailErr := repo.Run("config", "user.email")
+	return nameErr == nil && emailErr == nil
+}
+
+// Commit records every change in the working tree, returning false
+// if there was nothing to commit.
+func (repo *Repo) Commit(message string) (bool, error) {
+	if _, err := repo.Run("add", "--all"); err != nil {
+		return false, err
+	}
+	status, err := repo.Run("status", "--porcelain")
+	if err != nil {
+		return false, err
+	}
+	if len(strings.TrimSpace(status)) == 0 {
+		return false, nil
+	}
+	args := []string{"commit", "--quiet", "--message", message}
+	if !repo.HasIdentity() {
+		args = append([]string{"-c", "user.name=" + DefaultName, "-c", "user.email=" + DefaultEmail}, args...)
+	}
+	if _, err = repo.Run(args...); err != nil {
+		return false, err
+	}
+	return true, nil
+}
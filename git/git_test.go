@@ -0,0 +1,14 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubcommand(t *testing.T) {
+	assert.Equal(t, "commit", subcommand([]string{"commit", "--quiet"}))
+	assert.Equal(t, "commit", subcommand([]string{"-c", "user.name=dotted", "-c", "user.email=dotted@localhost", "commit"}))
+	assert.Equal(t, "status", subcommand([]string{"-C", "/tmp", "--no-pager", "status"}))
+	assert.Equal(t, "--version", subcommand([]string{"--version"}))
+}
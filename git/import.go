@@ -0,0 +1,60 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RedDocMD/dotted/file"
+	"github.com/pkg/errors"
+)
+
+// ImportHistory builds a history from every commit in the repository
+// at dir which changed the file at path, oldest first, keeping the
+// timestamps and messages of the commits. It returns the root and
+// the newest node. The repository may be bare, as used for keeping
+// dot-files in git with $HOME as the work tree.
+func ImportHistory(dir, path string) (*file.HistoryNode, *file.HistoryNode, error) {
+	repo := Open(dir)
+	// Fields are separated by NUL and commits by RS, since messages
+	// can contain newlines
+	out, err := repo.Run("log", "--reverse", "--format=%H%x00%at%x00%B%x1e", "--", path)
+	if err != nil {
+		return nil, nil, errors.WithMessagef(err, "failed to import %s", path)
+	}
+	var root, tip *file.HistoryNode
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		hash, message := fields[0], strings.TrimSpace(fields[2])
+		unixTime, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to import %s: invalid time of %s", path, hash)
+		}
+		timestamp := time.Unix(unixTime, 0)
+		content, err := repo.Run("show", hash+":"+path)
+		if err != nil {
+			// The file was deleted by this commit
+			continue
+		}
+		var node *file.HistoryNode
+		if root == nil {
+			root = file.NewHistory(content, timestamp)
+			node = root
+		} else {
+			node = tip.AddCommit(content, timestamp)
+		}
+		if node == nil {
+			continue
+		}
+		node.SetMessage(message)
+		tip = node
+	}
+	if root == nil {
+		return nil, nil, fmt.Errorf("failed to import %s: no commits found in %s", path, dir)
+	}
+	return root, tip, nil
+}
@@ -0,0 +1,56 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func commitFile(t *testing.T, repo *Repo, path, content, message string, date time.Time) {
+	fullPath := filepath.Join(repo.Dir, path)
+	os.MkdirAll(filepath.Dir(fullPath), 0755)
+	if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	repo.Env = []string{
+		"GIT_AUTHOR_DATE=" + date.Format(time.RFC3339),
+		"GIT_COMMITTER_DATE=" + date.Format(time.RFC3339),
+	}
+	if _, err := repo.Commit(message); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImportHistory(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	assert := assert.New(t)
+	repo, err := Init(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := time.Date(2019, 3, 1, 10, 0, 0, 0, time.UTC)
+	commitFile(t, repo, ".tmux.conf", "set -g mouse on\n", "Add tmux config", first)
+	commitFile(t, repo, ".bashrc", "alias ll='ls -l'\n", "Add bashrc", first.Add(time.Hour))
+	commitFile(t, repo, ".tmux.conf", "set -g mouse on\nset -g base-index 1\n",
+		"Count windows from 1\n\nLike the keyboard.", first.Add(48*time.Hour))
+
+	root, tip, err := ImportHistory(repo.Dir, ".tmux.conf")
+	assert.Nil(err)
+	assert.Equal("set -g mouse on\n", root.Content())
+	assert.Equal("Add tmux config", root.Message())
+	assert.True(root.Timestamp().Equal(first))
+	assert.Len(root.Children(), 1)
+	assert.Equal(tip, root.Children()[0])
+	assert.Equal("set -g mouse on\nset -g base-index 1\n", tip.Content())
+	assert.Equal("Count windows from 1\n\nLike the keyboard.", tip.Message())
+	assert.True(tip.Timestamp().Equal(first.Add(48 * time.Hour)))
+
+	_, _, err = ImportHistory(repo.Dir, ".vimrc")
+	assert.NotNil(err)
+}
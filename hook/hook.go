@@ -0,0 +1,68 @@
+package hook
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// Events a hook runs on, named as in the config
+const (
+	PreCommit  = "preCommit"
+	PostCommit = "postCommit"
+	PreApply   = "preApply"
+	PostApply  = "postApply"
+)
+
+// Env describes the file a hook runs for. It is passed to the hook
+// in DOTTED_* environment variables.
+type Env struct {
+	Event    string
+	Path     string // Where the file is deployed
+	Source   string // What commit reads, which differs from Path for templates and symlinks
+	Mnemonic string
+	// Current commit before and after the commit or apply, which
+	// are empty for files without history. NewCommit is only set for
+	// post-events, as pre-events run before the commit exists.
+	OldCommit string
+	NewCommit string
+}
+
+func (env Env) variables() []string {
+	return []string{
+		"DOTTED_EVENT=" + env.Event,
+		"DOTTED_PATH=" + env.Path,
+		"DOTTED_SOURCE=" + env.Source,
+		"DOTTED_MNEMONIC=" + env.Mnemonic,
+		"DOTTED_OLD_COMMIT=" + env.OldCommit,
+		"DOTTED_NEW_COMMIT=" + env.NewCommit,
+	}
+}
+
+// Shell runs each hook command with -c.
+var Shell = "sh"
+
+// Where the output of hooks goes
+var Stdout io.Writer = os.Stdout
+var Stderr io.Writer = os.Stderr
+
+// Run runs commands in order from $HOME, stopping at the first one
+// which fails.
+func Run(commands []string, env Env) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to run %s hook: %v", env.Event, err)
+	}
+	for _, command := range commands {
+		cmd := exec.Command(Shell, "-c", command)
+		cmd.Dir = home
+		cmd.Env = append(os.Environ(), env.variables()...)
+		cmd.Stdout = Stdout
+		cmd.Stderr = Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s hook %q failed: %v", env.Event, command, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package hook
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	if _, err := exec.LookPath(Shell); err != nil {
+		t.Skip("no shell installed")
+	}
+	var out bytes.Buffer
+	stdout := Stdout
+	Stdout = &out
+	defer func() { Stdout = stdout }()
+
+	env := Env{
+		Event:     PostCommit,
+		Path:      "/home/dknite/.tmux.conf",
+		Source:    "/home/dknite/.tmux.conf",
+		Mnemonic:  "tmux",
+		OldCommit: "d032a2c2-d846-4f68-b055-5964a210d194",
+		NewCommit: "887cd650-21c0-4d1f-8e3f-c76425f550b2",
+	}
+	err := Run([]string{
+		`echo "$DOTTED_EVENT $DOTTED_MNEMONIC $DOTTED_PATH"`,
+		`echo "$DOTTED_OLD_COMMIT -> $DOTTED_NEW_COMMIT"`,
+	}, env)
+	assert.Nil(t, err)
+	assert.Equal(t, "postCommit tmux /home/dknite/.tmux.conf\n"+
+		"d032a2c2-d846-4f68-b055-5964a210d194 -> 887cd650-21c0-4d1f-8e3f-c76425f550b2\n", out.String())
+
+	out.Reset()
+	env.Event = PreCommit
+	err = Run([]string{"echo first", "exit 3", "echo never"}, env)
+	assert.EqualError(t, err, `preCommit hook "exit 3" failed: exit status 3`)
+	assert.Equal(t, "first\n", out.String())
+}
@@ -0,0 +1,115 @@
+package importer
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/RedDocMD/dotted/config"
+	"github.com/pkg/errors"
+)
+
+// Prefixes of chezmoi source names which only set attributes that
+// dotted does not track, so they are dropped.
+var chezmoiAttributes = []string{"private_", "readonly_", "empty_", "executable_", "exact_"}
+
+// Prefixes and suffixes of chezmoi source names whose target is not
+// simply the content of the source file.
+var chezmoiUnsupported = map[string]string{
+	"encrypted_": "encrypted by chezmoi",
+	"create_":    "create-only file",
+	"modify_":    "modify script",
+	"remove_":    "removal",
+	"run_":       "script",
+	"symlink_":   "symlink",
+}
+
+// Chezmoi finds the files in the chezmoi source directory dir,
+// decoding the dot_, private_ and other attribute prefixes of their
+// names. Templates, scripts and encrypted files are skipped.
+func Chezmoi(dir string, taken map[string]struct{}) (Result, error) {
+	var result Result
+	err := Afs.Walk(dir, func(sourcePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(dir, sourcePath)
+		if err != nil || relPath == "." {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		// Names starting with a dot are chezmoi's own files, such as
+		// .chezmoiignore, or ones it ignores, such as .git
+		if strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+		targetPath, reason := decodeChezmoiPath(relPath)
+		if len(reason) != 0 {
+			result.skip(relPath, reason)
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		content, err := Afs.ReadFile(sourcePath)
+		if err != nil {
+			return err
+		}
+		result.Candidates = append(result.Candidates, Candidate{
+			Entry:   config.FileEntry{Path: targetPath},
+			Content: string(content),
+		})
+		return nil
+	})
+	if err != nil {
+		return result, errors.Wrap(err, "failed to read chezmoi source directory")
+	}
+	sort.Slice(result.Candidates, func(i, j int) bool {
+		return result.Candidates[i].Entry.Path < result.Candidates[j].Entry.Path
+	})
+	assignMnemonics(result.Candidates, taken)
+	return result, nil
+}
+
+// decodeChezmoiPath turns a source path into the target path
+// relative to $HOME, or gives the reason it cannot be imported.
+func decodeChezmoiPath(relPath string) (string, string) {
+	parts := strings.Split(relPath, "/")
+	for i, part := range parts {
+		if strings.HasSuffix(part, ".tmpl") {
+			return "", "template"
+		}
+		for {
+			trimmed := part
+			for _, prefix := range chezmoiAttributes {
+				trimmed = strings.TrimPrefix(trimmed, prefix)
+			}
+			if trimmed == part {
+				break
+			}
+			part = trimmed
+		}
+		for prefix, reason := range chezmoiUnsupported {
+			if strings.HasPrefix(part, prefix) {
+				return "", reason
+			}
+		}
+		if strings.HasPrefix(part, "dot_") {
+			part = "." + strings.TrimPrefix(part, "dot_")
+		}
+		part = strings.TrimPrefix(part, "literal_")
+		parts[i] = part
+	}
+	return path.Join(parts...), ""
+}
@@ -0,0 +1,77 @@
+package importer
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/RedDocMD/dotted/config"
+	"github.com/RedDocMD/dotted/fs"
+)
+
+var Fs = fs.OsFs
+var Afs = fs.OsAfs
+
+// Candidate is a file found in the layout of another dot-file
+// manager, which can be added to the config and the store.
+type Candidate struct {
+	Entry   config.FileEntry // Path is relative to $HOME
+	Content string
+}
+
+// Result is what was found in a layout.
+type Result struct {
+	Candidates []Candidate
+	// Source files which cannot be imported, such as templates or
+	// scripts, with the reason why
+	Skipped []string
+}
+
+func (result *Result) skip(sourcePath, reason string) {
+	result.Skipped = append(result.Skipped, fmt.Sprintf("%s: %s", sourcePath, reason))
+}
+
+// Names which say little about a file on their own, so the name of
+// the parent directory is used for the mnemonic instead.
+var genericNames = map[string]struct{}{
+	"":         {},
+	"config":   {},
+	"init":     {},
+	"settings": {},
+	"rc":       {},
+}
+
+// assignMnemonics gives every candidate a unique mnemonic derived
+// from its path, such as tmux for .tmux.conf and fish for
+// .config/fish/config.fish, unless taken is already using it.
+func assignMnemonics(candidates []Candidate, taken map[string]struct{}) {
+	used := make(map[string]struct{}, len(taken))
+	for mnemonic := range taken {
+		used[mnemonic] = struct{}{}
+	}
+	for i := range candidates {
+		entryPath := candidates[i].Entry.Path
+		name := trimName(path.Base(entryPath))
+		if _, generic := genericNames[name]; generic && path.Dir(entryPath) != "." {
+			name = trimName(path.Base(path.Dir(entryPath)))
+		}
+		mnemonic := name
+		for n := 2; ; n++ {
+			if _, ok := used[mnemonic]; !ok {
+				break
+			}
+			mnemonic = fmt.Sprintf("%s-%d", name, n)
+		}
+		used[mnemonic] = struct{}{}
+		candidates[i].Entry.Mnemonic = mnemonic
+	}
+}
+
+// trimName drops leading dots and everything from the next dot on.
+func trimName(name string) string {
+	name = strings.TrimLeft(name, ".")
+	if i := strings.Index(name, "."); i != -1 {
+		name = name[:i]
+	}
+	return name
+}
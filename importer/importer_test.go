@@ -0,0 +1,155 @@
+package importer
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/RedDocMD/dotted/fs"
+	"github.com/RedDocMD/dotted/git"
+	"github.com/stretchr/testify/suite"
+)
+
+type ImporterSuite struct {
+	suite.Suite
+}
+
+func (suite *ImporterSuite) SetupSuite() {
+	Fs = fs.MockFs
+	Afs = fs.MockAfs
+}
+
+func (suite *ImporterSuite) TearDownSuite() {
+	Fs = fs.OsFs
+	Afs = fs.OsAfs
+}
+
+func (suite *ImporterSuite) TestStow() {
+	assert := suite.Assert()
+	files := map[string]string{
+		"stow/tmux/.tmux.conf":                   "set -g mouse on\n",
+		"stow/fish/dot-config/fish/config.fish":  "set -x EDITOR vim\n",
+		"stow/fish/dot-config/fish/fish_plugins": "jorgebucaran/fisher\n",
+		"stow/nvim/.config/nvim/init.lua":        "vim.o.number = true\n",
+		"stow/nvim/README.md":                    "My neovim config\n",
+		"stow/nvim/.config/nvim/init.lua~":       "backup\n",
+		"stow/.git/HEAD":                         "ref: refs/heads/main\n",
+		"stow/README.md":                         "My dot-files\n",
+	}
+	for path, content := range files {
+		Afs.MkdirAll(filepath.Dir(path), 0755)
+		Afs.WriteFile(path, []byte(content), 0644)
+	}
+
+	result, err := Stow("stow", map[string]struct{}{"tmux": {}})
+	assert.Nil(err)
+	assert.Len(result.Candidates, 4)
+	expected := [][3]string{
+		{".config/fish/config.fish", "fish", "set -x EDITOR vim\n"},
+		{".config/fish/fish_plugins", "fish_plugins", "jorgebucaran/fisher\n"},
+		{".config/nvim/init.lua", "nvim", "vim.o.number = true\n"},
+		{".tmux.conf", "tmux-2", "set -g mouse on\n"},
+	}
+	for i, candidate := range result.Candidates {
+		assert.Equal(expected[i][0], candidate.Entry.Path)
+		assert.Equal(expected[i][1], candidate.Entry.Mnemonic)
+		assert.Equal(expected[i][2], candidate.Content)
+	}
+
+	_, err = Stow("missing", nil)
+	assert.NotNil(err)
+}
+
+func (suite *ImporterSuite) TestChezmoi() {
+	assert := suite.Assert()
+	files := map[string]string{
+		"chezmoi/dot_bashrc":                               "alias ll='ls -l'\n",
+		"chezmoi/private_dot_ssh/private_config":           "Host *\n",
+		"chezmoi/dot_config/alacritty/alacritty.yml":       "font:\n  size: 11\n",
+		"chezmoi/executable_dot_local/bin/literal_dot_foo": "#!/bin/sh\n",
+		"chezmoi/dot_gitconfig.tmpl":                       "[user]\n  name = {{ .name }}\n",
+		"chezmoi/encrypted_dot_netrc.age":                  "age\n",
+		"chezmoi/run_once_install.sh":                      "#!/bin/sh\n",
+		"chezmoi/.chezmoiignore":                           "README.md\n",
+		"chezmoi/.git/HEAD":                                "ref: refs/heads/main\n",
+	}
+	for path, content := range files {
+		Afs.MkdirAll(filepath.Dir(path), 0755)
+		Afs.WriteFile(path, []byte(content), 0644)
+	}
+
+	result, err := Chezmoi("chezmoi", nil)
+	assert.Nil(err)
+	assert.Len(result.Candidates, 4)
+	expected := [][3]string{
+		{".bashrc", "bashrc", "alias ll='ls -l'\n"},
+		{".config/alacritty/alacritty.yml", "alacritty", "font:\n  size: 11\n"},
+		{".local/bin/dot_foo", "dot_foo", "#!/bin/sh\n"},
+		{".ssh/config", "ssh", "Host *\n"},
+	}
+	for i, candidate := range result.Candidates {
+		assert.Equal(expected[i][0], candidate.Entry.Path)
+		assert.Equal(expected[i][1], candidate.Entry.Mnemonic)
+		assert.Equal(expected[i][2], candidate.Content)
+	}
+	assert.ElementsMatch([]string{
+		"dot_gitconfig.tmpl: template",
+		"encrypted_dot_netrc.age: encrypted by chezmoi",
+		"run_once_install.sh: script",
+	}, result.Skipped)
+}
+
+func TestImporterSuite(t *testing.T) {
+	suite.Run(t, new(ImporterSuite))
+}
+
+func TestYadm(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	home := t.TempDir()
+	files := map[string]string{
+		".vimrc":                 "set number\n",
+		".config/git/config":     "[core]\n  pager = less\n",
+		".bashrc##os.Linux":      "alias ls='ls --color'\n",
+		".config/yadm/bootstrap": "#!/bin/sh\n",
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(home, path)
+		os.MkdirAll(filepath.Dir(fullPath), 0755)
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repoDir := t.TempDir()
+	repo, err := git.Init(repoDir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo.Env = []string{"GIT_WORK_TREE=" + home}
+	if _, err = repo.Commit("Add dot-files"); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(home, ".vimrc"), []byte("uncommitted\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Yadm(repoDir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Candidates) != 2 {
+		t.Fatalf("expected 2 candidates, got %v", result.Candidates)
+	}
+	git, vim := result.Candidates[0], result.Candidates[1]
+	if git.Entry.Path != ".config/git/config" || git.Entry.Mnemonic != "git" {
+		t.Errorf("unexpected entry %v", git.Entry)
+	}
+	if vim.Entry.Path != ".vimrc" || vim.Entry.Mnemonic != "vimrc" || vim.Content != "set number\n" {
+		t.Errorf("unexpected candidate %v", vim)
+	}
+	if len(result.Skipped) != 2 {
+		t.Errorf("expected 2 skipped files, got %v", result.Skipped)
+	}
+}
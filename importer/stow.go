@@ -0,0 +1,90 @@
+package importer
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/RedDocMD/dotted/config"
+	"github.com/pkg/errors"
+)
+
+// Stow finds the files in every package of the stow directory dir,
+// assuming $HOME is the target directory. Names starting with dot-
+// are decoded as with stow --dotfiles.
+func Stow(dir string, taken map[string]struct{}) (Result, error) {
+	var result Result
+	packages, err := Afs.ReadDir(dir)
+	if err != nil {
+		return result, errors.Wrap(err, "failed to read stow directory")
+	}
+	for _, pkg := range packages {
+		if !pkg.IsDir() || isStowIgnored(pkg.Name(), true) {
+			continue
+		}
+		pkgDir := Fs.Join(dir, pkg.Name())
+		err = Afs.Walk(pkgDir, func(sourcePath string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			relPath, err := filepath.Rel(pkgDir, sourcePath)
+			if err != nil || relPath == "." {
+				return err
+			}
+			relPath = filepath.ToSlash(relPath)
+			if isStowIgnored(info.Name(), !strings.Contains(relPath, "/")) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			content, err := Afs.ReadFile(sourcePath)
+			if err != nil {
+				return err
+			}
+			result.Candidates = append(result.Candidates, Candidate{
+				Entry:   config.FileEntry{Path: decodeStowPath(relPath)},
+				Content: string(content),
+			})
+			return nil
+		})
+		if err != nil {
+			return result, errors.Wrapf(err, "failed to read stow package %s", pkg.Name())
+		}
+	}
+	sort.Slice(result.Candidates, func(i, j int) bool {
+		return result.Candidates[i].Entry.Path < result.Candidates[j].Entry.Path
+	})
+	assignMnemonics(result.Candidates, taken)
+	return result, nil
+}
+
+// isStowIgnored follows the default ignore list of stow.
+func isStowIgnored(name string, topLevel bool) bool {
+	switch name {
+	case ".git", ".gitignore", ".gitmodules", ".hg", ".svn", "CVS", "RCS", "_darcs",
+		".cvsignore", ".stow-local-ignore", ".stow":
+		return true
+	}
+	if strings.HasSuffix(name, "~") || strings.HasPrefix(name, ".#") ||
+		(strings.HasPrefix(name, "#") && strings.HasSuffix(name, "#")) {
+		return true
+	}
+	return topLevel && (strings.HasPrefix(name, "README") ||
+		strings.HasPrefix(name, "LICENSE") || name == "COPYING")
+}
+
+func decodeStowPath(relPath string) string {
+	parts := strings.Split(relPath, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, "dot-") {
+			parts[i] = "." + strings.TrimPrefix(part, "dot-")
+		}
+	}
+	return path.Join(parts...)
+}
@@ -0,0 +1,56 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/RedDocMD/dotted/config"
+	"github.com/RedDocMD/dotted/git"
+	"github.com/pkg/errors"
+)
+
+// YadmRepo is the path of the yadm repository relative to $HOME.
+const YadmRepo = ".local/share/yadm/repo.git"
+
+// Yadm finds the files committed to the yadm repository at repo,
+// which is bare with $HOME as its work tree, with the content they
+// have at HEAD. Alternate files, whose names carry ## conditions,
+// are skipped.
+func Yadm(repo string, taken map[string]struct{}) (Result, error) {
+	var result Result
+	if len(repo) == 0 {
+		repo = Fs.Join(Fs.UserHomeDir(), YadmRepo)
+	}
+	if _, err := os.Stat(repo); err != nil {
+		return result, fmt.Errorf("failed to read yadm repository: %s does not exist", repo)
+	}
+	gitRepo := git.Open(repo)
+	out, err := gitRepo.Run("ls-tree", "-r", "-z", "--name-only", "HEAD")
+	if err != nil {
+		return result, errors.WithMessage(err, "failed to read yadm repository")
+	}
+	for _, relPath := range strings.Split(out, "\x00") {
+		if len(relPath) == 0 {
+			continue
+		}
+		if strings.Contains(relPath, "##") {
+			result.skip(relPath, "alternate file")
+			continue
+		}
+		if strings.HasPrefix(relPath, ".config/yadm/") {
+			result.skip(relPath, "yadm configuration")
+			continue
+		}
+		content, err := gitRepo.Run("show", "HEAD:"+relPath)
+		if err != nil {
+			return result, errors.WithMessagef(err, "failed to read %s from yadm repository", relPath)
+		}
+		result.Candidates = append(result.Candidates, Candidate{
+			Entry:   config.FileEntry{Path: relPath},
+			Content: content,
+		})
+	}
+	assignMnemonics(result.Candidates, taken)
+	return result, nil
+}
@@ -0,0 +1,84 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of the output of commands
+const (
+	Table = "table" // For people, with tables and coloured messages
+	JSON  = "json"
+	YAML  = "yaml"
+)
+
+// Format is the format chosen with --output.
+var Format = Table
+
+// Writer is where structured output goes.
+var Writer io.Writer = os.Stdout
+
+// SetFormat checks and sets the output format.
+func SetFormat(format string) error {
+	switch format {
+	case Table, JSON, YAML:
+		Format = format
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q, expected %s, %s or %s", format, Table, JSON, YAML)
+	}
+}
+
+// IsStructured reports whether commands print JSON or YAML rather
+// than tables and messages.
+func IsStructured() bool {
+	return Format != Table
+}
+
+// Print writes value in the output format, as indented JSON or as a
+// YAML document.
+func Print(value interface{}) error {
+	switch Format {
+	case JSON:
+		encoder := json.NewEncoder(Writer)
+		encoder.SetIndent("", "  ")
+		return errors.Wrap(encoder.Encode(value), "failed to print output")
+	case YAML:
+		return printYAML(value, false)
+	default:
+		return fmt.Errorf("cannot print structured output as %s", Format)
+	}
+}
+
+// PrintStream writes value as one item of a stream, for commands
+// which keep running. JSON items take one line each, and YAML items
+// are separate documents.
+func PrintStream(value interface{}) error {
+	switch Format {
+	case JSON:
+		return errors.Wrap(json.NewEncoder(Writer).Encode(value), "failed to print output")
+	case YAML:
+		return printYAML(value, true)
+	default:
+		return fmt.Errorf("cannot print structured output as %s", Format)
+	}
+}
+
+func printYAML(value interface{}, separate bool) error {
+	if separate {
+		if _, err := io.WriteString(Writer, "---\n"); err != nil {
+			return errors.Wrap(err, "failed to print output")
+		}
+	}
+	encoder := yaml.NewEncoder(Writer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(value); err != nil {
+		return errors.Wrap(err, "failed to print output")
+	}
+	return errors.Wrap(encoder.Close(), "failed to print output")
+}
@@ -0,0 +1,101 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrint(t *testing.T) {
+	var out bytes.Buffer
+	writer := Writer
+	Writer = &out
+	defer func() {
+		Writer = writer
+		Format = Table
+	}()
+
+	assert.EqualError(t, SetFormat("xml"), `unknown output format "xml", expected table, json or yaml`)
+	assert.False(t, IsStructured())
+	assert.NotNil(t, Print(Dropped{}))
+
+	dropped := []Dropped{{Path: "/home/dknite/.vimrc", Versions: 3}}
+	assert.Nil(t, SetFormat(JSON))
+	assert.True(t, IsStructured())
+	assert.Nil(t, Print(dropped))
+	assert.Equal(t, `[
+  {
+    "path": "/home/dknite/.vimrc",
+    "versions": 3
+  }
+]
+`, out.String())
+
+	out.Reset()
+	assert.Nil(t, PrintStream(dropped[0]))
+	assert.Nil(t, PrintStream(dropped[0]))
+	assert.Equal(t, `{"path":"/home/dknite/.vimrc","versions":3}
+{"path":"/home/dknite/.vimrc","versions":3}
+`, out.String())
+
+	out.Reset()
+	assert.Nil(t, SetFormat(YAML))
+	assert.Nil(t, Print(dropped))
+	assert.Equal(t, `- path: /home/dknite/.vimrc
+  versions: 3
+`, out.String())
+
+	out.Reset()
+	assert.Nil(t, PrintStream(dropped[0]))
+	assert.Nil(t, PrintStream(dropped[0]))
+	assert.Equal(t, `---
+path: /home/dknite/.vimrc
+versions: 3
+---
+path: /home/dknite/.vimrc
+versions: 3
+`, out.String())
+}
+
+func TestNewFile(t *testing.T) {
+	file.Fs = fs.MockFs
+	file.Afs = fs.MockAfs
+	defer func() {
+		file.Fs.RemoveAll("/")
+		file.Fs = fs.OsFs
+		file.Afs = fs.OsAfs
+	}()
+	file.Afs.WriteFile("/home/dknite/.config/kitty/kitty.conf", []byte("font_size 11\n"), 0644)
+
+	dotFile, err := file.NewDotFile("/home/dknite/.config/kitty/kitty.conf", "kitty", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, File{
+		Path:          "/home/dknite/.config/kitty/kitty.conf",
+		RelativePath:  ".config/kitty/kitty.conf",
+		Mnemonic:      "kitty",
+		HasHistory:    true,
+		Deploy:        "copy",
+		WorkPath:      "/home/dknite/.config/kitty/kitty.conf",
+		CurrentCommit: dotFile.CurrentHistory().UUID(),
+		StorePath:     "/home/dknite/.dotted/store/" + dotFile.RelativePathHash(),
+	}, NewFile(dotFile, "/home/dknite/.dotted/store"))
+
+	root := dotFile.CurrentHistory()
+	file.Afs.WriteFile("/home/dknite/.config/kitty/kitty.conf", []byte("font_size 12\n"), 0644)
+	dotFile.AddCommit()
+	history := NewHistory(dotFile.Path(), dotFile.HistoryRoot(), dotFile.CurrentHistory())
+	assert.Equal(t, dotFile.CurrentHistory().UUID(), history.CurrentCommit)
+	if assert.Len(t, history.Commits, 2) {
+		assert.Equal(t, root.UUID(), history.Commits[0].UUID)
+		assert.Equal(t, []string{history.Commits[1].UUID}, history.Commits[0].Children)
+		assert.False(t, history.Commits[0].Current)
+		assert.Equal(t, root.UUID(), history.Commits[1].Parent)
+		assert.Equal(t, []string{}, history.Commits[1].Children)
+		assert.True(t, history.Commits[1].Current)
+	}
+}
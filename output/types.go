@@ -0,0 +1,268 @@
+package output
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/RedDocMD/dotted/config"
+	"github.com/RedDocMD/dotted/file"
+)
+
+// The structures printed by commands with --output json or yaml.
+// Fields are only ever added to them, never renamed or removed.
+
+// File is a tracked dot-file, as printed by list and add and given
+// to plugins.
+type File struct {
+	Path         string `json:"path" yaml:"path"`                 // Absolute
+	RelativePath string `json:"relativePath" yaml:"relativePath"` // Relative to $HOME, as in the config
+	Mnemonic     string `json:"mnemonic" yaml:"mnemonic"`
+	HasHistory   bool   `json:"hasHistory" yaml:"hasHistory"`
+	Encrypted    bool   `json:"encrypted" yaml:"encrypted"`
+	// How the file is deployed: copy, symlink or template
+	Deploy string `json:"deploy" yaml:"deploy"`
+	// What commit reads, which differs from Path for templates and
+	// files deployed as symlinks
+	WorkPath string `json:"workPath" yaml:"workPath"`
+	// UUID of the current commit, empty for files without history
+	CurrentCommit string `json:"currentCommit,omitempty" yaml:"currentCommit,omitempty"`
+	StorePath     string `json:"storePath" yaml:"storePath"` // Directory of the file in the store
+	Archived      bool   `json:"archived" yaml:"archived"`   // Left the config, see list --archived
+}
+
+// Deploy modes of File which are not config.DeployMode values
+const TemplateDeploy = "template"
+
+// NewFile describes dotFile, which is kept in the store at
+// storeLocation.
+func NewFile(dotFile *file.DotFile, storeLocation string) File {
+	described := File{
+		Path:         dotFile.Path(),
+		RelativePath: filepath.ToSlash(dotFile.RelativePath()),
+		Mnemonic:     dotFile.Mnemonic(),
+		HasHistory:   dotFile.HasHistory(),
+		Encrypted:    dotFile.IsEncrypted(),
+		Deploy:       config.CopyDeploy,
+		WorkPath:     dotFile.WorkPath(),
+		StorePath:    filepath.Join(storeLocation, dotFile.RelativePathHash()),
+	}
+	if dotFile.IsTemplate() {
+		described.Deploy = TemplateDeploy
+	} else if dotFile.IsSymlinked() {
+		described.Deploy = config.SymlinkDeploy
+	}
+	if dotFile.HasHistory() {
+		described.CurrentCommit = dotFile.CurrentHistory().UUID()
+	}
+	return described
+}
+
+// NewFiles describes dotFiles, which are kept in the store at
+// storeLocation.
+func NewFiles(dotFiles []*file.DotFile, storeLocation string) []File {
+	files := make([]File, 0, len(dotFiles))
+	for _, dotFile := range dotFiles {
+		files = append(files, NewFile(dotFile, storeLocation))
+	}
+	return files
+}
+
+// Status is how a file on disk compares to the store, as printed by
+// status. State is one of clean, modified, missing, unlinked, broken,
+// hijacked or edited.
+type Status struct {
+	Path   string `json:"path" yaml:"path"`
+	Deploy string `json:"deploy" yaml:"deploy"`
+	State  string `json:"state" yaml:"state"`
+}
+
+// Commit is a node in the history of a file.
+type Commit struct {
+	UUID      string    `json:"uuid" yaml:"uuid"`
+	Parent    string    `json:"parent,omitempty" yaml:"parent,omitempty"` // Empty for the first commit
+	Children  []string  `json:"children" yaml:"children"`
+	Timestamp time.Time `json:"timestamp" yaml:"timestamp"`
+	Message   string    `json:"message,omitempty" yaml:"message,omitempty"`
+	Tags      []string  `json:"tags" yaml:"tags"`
+	Current   bool      `json:"current" yaml:"current"`
+}
+
+// History is the history of a file, as printed by history --list.
+// Commits are in depth-first order from the first commit, so every
+// commit comes after its parent.
+type History struct {
+	Path          string   `json:"path" yaml:"path"`
+	CurrentCommit string   `json:"currentCommit" yaml:"currentCommit"`
+	Commits       []Commit `json:"commits" yaml:"commits"`
+}
+
+// NewHistory describes the history rooted at root, with current as
+// the current commit, of the file at path.
+func NewHistory(path string, root, current *file.HistoryNode) History {
+	history := History{Path: path, CurrentCommit: current.UUID(), Commits: []Commit{}}
+	var add func(node *file.HistoryNode)
+	add = func(node *file.HistoryNode) {
+		commit := Commit{
+			UUID:      node.UUID(),
+			Children:  []string{},
+			Timestamp: node.Timestamp(),
+			Message:   node.Message(),
+			Tags:      append([]string{}, node.Tags()...),
+			Current:   node == current,
+		}
+		if node.Parent() != nil {
+			commit.Parent = node.Parent().UUID()
+		}
+		for _, child := range node.Children() {
+			commit.Children = append(commit.Children, child.UUID())
+		}
+		history.Commits = append(history.Commits, commit)
+		for _, child := range node.Children() {
+			add(child)
+		}
+	}
+	add(root)
+	return history
+}
+
+// Version is the content of a file at a commit, as printed by
+// history --view.
+type Version struct {
+	Path      string    `json:"path" yaml:"path"`
+	Commit    string    `json:"commit" yaml:"commit"`
+	Timestamp time.Time `json:"timestamp" yaml:"timestamp"`
+	Content   string    `json:"content" yaml:"content"`
+}
+
+// Results of commit, and of watch for each file it commits
+const (
+	Committed = "committed" // A new commit of a file with history
+	Updated   = "updated"   // New content of a file without history
+	Skipped   = "skipped"   // Stopped by a check, see Reason
+)
+
+// CommitResult is a changed file, as printed by commit and watch.
+type CommitResult struct {
+	Path      string `json:"path" yaml:"path"`
+	Result    string `json:"result" yaml:"result"`
+	OldCommit string `json:"oldCommit,omitempty" yaml:"oldCommit,omitempty"`
+	NewCommit string `json:"newCommit,omitempty" yaml:"newCommit,omitempty"`
+	Reason    string `json:"reason,omitempty" yaml:"reason,omitempty"`
+	// When the file was committed, only set by watch
+	Time *time.Time `json:"time,omitempty" yaml:"time,omitempty"`
+}
+
+// ApplyResult is a file written by apply. Action is create, replace
+// or unchanged, or skipped with a Reason.
+type ApplyResult struct {
+	Path    string   `json:"path" yaml:"path"`
+	Action  string   `json:"action" yaml:"action"`
+	Backups []string `json:"backups" yaml:"backups"` // Where replaced files were moved
+	Reason  string   `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+// FileDiff is the uncommitted change to a file, as printed by diff.
+// Semantic diffs have Changes and line diffs have Hunks.
+type FileDiff struct {
+	Path     string `json:"path" yaml:"path"`
+	Semantic bool   `json:"semantic" yaml:"semantic"`
+	// Why a semantic diff fell back to a line diff
+	Fallback string      `json:"fallback,omitempty" yaml:"fallback,omitempty"`
+	Changes  []KeyChange `json:"changes,omitempty" yaml:"changes,omitempty"`
+	Hunks    []Hunk      `json:"hunks,omitempty" yaml:"hunks,omitempty"`
+}
+
+// KeyChange is a value which changed, was added or was removed, at a
+// key path such as font.size.
+type KeyChange struct {
+	Path string      `json:"path" yaml:"path"`
+	Kind string      `json:"kind" yaml:"kind"` // added, removed or changed
+	Old  interface{} `json:"old,omitempty" yaml:"old,omitempty"`
+	New  interface{} `json:"new,omitempty" yaml:"new,omitempty"`
+}
+
+// Hunk is a part of a line diff. Lines start with a space, + or -,
+// as in a unified diff.
+type Hunk struct {
+	Header string   `json:"header" yaml:"header"`
+	Lines  []string `json:"lines" yaml:"lines"`
+}
+
+// Dropped is a file whose history was deleted by history --drop.
+type Dropped struct {
+	Path     string `json:"path" yaml:"path"`
+	Versions int    `json:"versions" yaml:"versions"`
+}
+
+// Transfer is what backup push and pull copied.
+type Transfer struct {
+	Target      string   `json:"target" yaml:"target"`
+	Transferred []string `json:"transferred" yaml:"transferred"` // Paths in the store
+	// Changed on the target but without history, so not pulled
+	Skipped   []string `json:"skipped" yaml:"skipped"`
+	Unchanged int      `json:"unchanged" yaml:"unchanged"`
+	// Encrypted, so not copied to a target holding readable copies
+	Encrypted []string `json:"encrypted" yaml:"encrypted"`
+}
+
+// Sync is what sync merged.
+type Sync struct {
+	Target    string         `json:"target" yaml:"target"`
+	Files     []FileSync     `json:"files" yaml:"files"`
+	Skipped   []string       `json:"skipped" yaml:"skipped"` // Not tracked with history on both sides
+	Conflicts []SyncConflict `json:"conflicts" yaml:"conflicts"`
+}
+
+// SyncConflict is a file whose history on the target could not be
+// merged, with the reason why. It is left as it is on both sides.
+type SyncConflict struct {
+	Path   string `json:"path" yaml:"path"`
+	Reason string `json:"reason" yaml:"reason"`
+}
+
+// FileSync is the merge of the history of a file. A file is behind
+// when its current commit is an ancestor of the tip on the target,
+// and diverged when neither is an ancestor of the other.
+type FileSync struct {
+	Path          string `json:"path" yaml:"path"`
+	Added         int    `json:"added" yaml:"added"` // Commits merged in
+	Behind        bool   `json:"behind" yaml:"behind"`
+	Diverged      bool   `json:"diverged" yaml:"diverged"`
+	CurrentCommit string `json:"currentCommit" yaml:"currentCommit"`
+	RemoteTip     string `json:"remoteTip" yaml:"remoteTip"`
+	// Tags on the target which name other commits, kept where they were
+	TagClashes []TagClash `json:"tagClashes" yaml:"tagClashes"`
+}
+
+// TagClash is a tag on the target which names another commit than the
+// local one. It is kept on the local commit.
+type TagClash struct {
+	Tag     string `json:"tag" yaml:"tag"`
+	Kept    string `json:"kept" yaml:"kept"`
+	Dropped string `json:"dropped" yaml:"dropped"`
+}
+
+// Export is a file exported by export git.
+type Export struct {
+	Path     string   `json:"path" yaml:"path"`
+	Repo     string   `json:"repo" yaml:"repo"`
+	Exported int      `json:"exported" yaml:"exported"` // New commits
+	Branches []string `json:"branches" yaml:"branches"` // One for every tip
+	Head     string   `json:"head" yaml:"head"`         // Branch or commit HEAD points to
+}
+
+// Import is what an import command added. Skipped lists files which
+// were not imported with the reason why.
+type Import struct {
+	Files   []File   `json:"files" yaml:"files"`
+	Commits int      `json:"commits,omitempty" yaml:"commits,omitempty"` // Imported by import git
+	Skipped []string `json:"skipped" yaml:"skipped"`
+	// Config entries which would be added, only set with --dry-run
+	Entries []Entry `json:"entries,omitempty" yaml:"entries,omitempty"`
+}
+
+// Entry is an entry of the config.
+type Entry struct {
+	Path     string `json:"path" yaml:"path"`
+	Mnemonic string `json:"mnemonic" yaml:"mnemonic"`
+}
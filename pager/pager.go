@@ -0,0 +1,118 @@
+package pager
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// Default is the pager when neither the config nor $PAGER has one.
+const Default = "less -R"
+
+// None turns paging off when it is the pager in the config.
+const None = "none"
+
+// Shell runs the pager command, which may have arguments.
+var Shell = "sh"
+
+// Command picks the pager, which is configured if it is set, or else
+// $PAGER, or else Default. It is empty if paging is off, with None in
+// the config, or an empty or cat $PAGER.
+func Command(configured string) string {
+	command := configured
+	if len(command) == 0 {
+		var ok bool
+		if command, ok = os.LookupEnv("PAGER"); !ok {
+			command = Default
+		}
+	}
+	command = strings.TrimSpace(command)
+	if command == None || command == "cat" {
+		return ""
+	}
+	return command
+}
+
+// Writer pages what is written to it. The pager starts with the first
+// write, so that nothing is paged if there is no output, and output
+// goes straight to out if it cannot start. Once the pager quits, the
+// rest of the output is thrown away.
+//
+// As with git, less is told to quit if the output fits on one screen
+// and to keep colours, unless $LESS is set, and the pager is left to
+// handle Ctrl-C while it runs.
+type Writer struct {
+	command string
+	out     io.Writer
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	started bool
+	quit    bool
+}
+
+// New makes a writer paging through command to out, which is the
+// terminal the pager takes over.
+func New(command string, out io.Writer) *Writer {
+	return &Writer{command: command, out: out}
+}
+
+func (writer *Writer) start() {
+	writer.started = true
+	cmd := exec.Command(Shell, "-c", writer.command)
+	cmd.Stdout = writer.out
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+	if _, ok := os.LookupEnv("LV"); !ok {
+		cmd.Env = append(cmd.Env, "LV=-c")
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return
+	}
+	if err = cmd.Start(); err != nil {
+		return
+	}
+	signal.Ignore(os.Interrupt)
+	writer.cmd, writer.stdin = cmd, stdin
+}
+
+func (writer *Writer) Write(p []byte) (int, error) {
+	if !writer.started {
+		writer.start()
+	}
+	if writer.quit {
+		return len(p), nil
+	}
+	if writer.cmd == nil {
+		return writer.out.Write(p)
+	}
+	n, err := writer.stdin.Write(p)
+	if errors.Is(err, syscall.EPIPE) || errors.Is(err, os.ErrClosed) {
+		writer.quit = true
+		return len(p), nil
+	}
+	return n, err
+}
+
+// Close ends the output and waits for the pager to quit.
+func (writer *Writer) Close() error {
+	if writer.cmd == nil {
+		return nil
+	}
+	writer.stdin.Close()
+	err := writer.cmd.Wait()
+	writer.cmd = nil
+	signal.Reset(os.Interrupt)
+	if _, ok := err.(*exec.ExitError); ok {
+		// Quitting less early is not a failure of the command
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+package pager
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommand(t *testing.T) {
+	pager, hasPager := os.LookupEnv("PAGER")
+	defer func() {
+		if hasPager {
+			os.Setenv("PAGER", pager)
+		} else {
+			os.Unsetenv("PAGER")
+		}
+	}()
+
+	os.Unsetenv("PAGER")
+	assert.Equal(t, Default, Command(""))
+	assert.Equal(t, "most", Command("most"))
+	assert.Equal(t, "", Command(None))
+
+	os.Setenv("PAGER", "more")
+	assert.Equal(t, "more", Command(""))
+	assert.Equal(t, "less -RS", Command("less -RS"))
+	os.Setenv("PAGER", "cat")
+	assert.Equal(t, "", Command(""))
+	os.Setenv("PAGER", "")
+	assert.Equal(t, "", Command(""))
+}
+
+func TestWriter(t *testing.T) {
+	if _, err := exec.LookPath(Shell); err != nil {
+		t.Skip("no shell installed")
+	}
+	paged := filepath.Join(t.TempDir(), "paged")
+	var out bytes.Buffer
+
+	writer := New(`printf "$LESS:" > `+paged+`; cat >> `+paged, &out)
+	assert.Nil(t, writer.Close())
+	_, err := os.Stat(paged)
+	assert.True(t, os.IsNotExist(err), "pager started without output")
+
+	_, err = writer.Write([]byte("first\n"))
+	assert.Nil(t, err)
+	_, err = writer.Write([]byte("second\n"))
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Close())
+	buf, err := os.ReadFile(paged)
+	assert.Nil(t, err)
+	if os.Getenv("LESS") == "" {
+		assert.Equal(t, "FRX:first\nsecond\n", string(buf))
+	}
+	assert.Equal(t, "", out.String())
+
+	// A pager which quits early takes the rest of the output with it
+	writer = New("exit 0", &out)
+	long := []byte(strings.Repeat("line\n", 100000))
+	for i := 0; i < 3; i++ {
+		n, err := writer.Write(long)
+		assert.Nil(t, err)
+		assert.Equal(t, len(long), n)
+	}
+	assert.Nil(t, writer.Close())
+	assert.Equal(t, "", out.String())
+}
@@ -0,0 +1,68 @@
+package plugin
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/RedDocMD/dotted/output"
+	"github.com/pkg/errors"
+)
+
+// Prefix of the executables which provide the subcommands dtd does
+// not have, such as dtd-sync-nas for dtd sync-nas.
+const Prefix = "dtd-"
+
+// Env is what a plugin is told about dotted.
+type Env struct {
+	Output        string // DOTTED_OUTPUT, the format given with --output
+	ConfigPath    string // DOTTED_CONFIG
+	StoreLocation string // DOTTED_STORE
+	// DOTTED_FILES, in the form printed by dtd list --output json
+	Files []output.File
+}
+
+// Find looks up the plugin for the subcommand name on $PATH.
+func Find(name string) (string, bool) {
+	path, err := exec.LookPath(Prefix + name)
+	return path, err == nil
+}
+
+// Run runs the plugin at path with args and the terminal of dtd,
+// returning its exit code. The files are written to a temporary JSON
+// file, rather than the environment which limits the size of
+// variables, and it is removed once the plugin exits.
+func Run(path string, args []string, env Env) (int, error) {
+	filesJSON, err := os.CreateTemp("", "dotted-files-*.json")
+	if err != nil {
+		return -1, errors.Wrap(err, "failed to run plugin")
+	}
+	defer os.Remove(filesJSON.Name())
+	err = json.NewEncoder(filesJSON).Encode(env.Files)
+	if closeErr := filesJSON.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return -1, errors.Wrap(err, "failed to run plugin")
+	}
+
+	cmd := exec.Command(path, args...)
+	cmd.Env = append(os.Environ(),
+		"DOTTED_OUTPUT="+env.Output,
+		"DOTTED_CONFIG="+env.ConfigPath,
+		"DOTTED_STORE="+env.StoreLocation,
+		"DOTTED_FILES="+filesJSON.Name(),
+	)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return -1, errors.Wrapf(err, "failed to run %s", filepath.Base(path))
+	}
+	return 0, nil
+}
@@ -0,0 +1,77 @@
+package plugin
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/fs"
+	"github.com/RedDocMD/dotted/output"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	file.Fs = fs.MockFs
+	file.Afs = fs.MockAfs
+	defer func() {
+		file.Fs = fs.OsFs
+		file.Afs = fs.OsAfs
+	}()
+	file.Afs.WriteFile("/home/dknite/.tmux.conf", []byte("set -g mouse on\n"), 0644)
+	defer file.Afs.RemoveAll("/")
+	dotFile, err := file.NewDotFile("/home/dknite/.tmux.conf", "tmux", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The plugin saves what it was given in out
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	script := "#!/bin/sh\n" +
+		`printf '%s\n' "$*" "$DOTTED_OUTPUT" "$DOTTED_CONFIG" "$DOTTED_STORE" > "` + out + `"` + "\n" +
+		`cat "$DOTTED_FILES" >> "` + out + `"` + "\n" +
+		"exit 3\n"
+	if err := os.WriteFile(filepath.Join(dir, Prefix+"echo"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	path, ok := Find("echo")
+	assert.True(t, ok)
+	_, ok = Find("missing")
+	assert.False(t, ok)
+
+	env := Env{
+		Output:        "json",
+		ConfigPath:    "/home/dknite/.dotted/dotted.yml",
+		StoreLocation: "/home/dknite/.dotted/store",
+		Files:         output.NewFiles([]*file.DotFile{dotFile}, "/home/dknite/.dotted/store"),
+	}
+	code, err := Run(path, []string{"a", "b"}, env)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, code)
+
+	buf, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := string(buf)
+	header := "a b\njson\n/home/dknite/.dotted/dotted.yml\n/home/dknite/.dotted/store\n"
+	if assert.Greater(t, len(lines), len(header)) {
+		assert.Equal(t, header, lines[:len(header)])
+		var files []output.File
+		assert.Nil(t, json.Unmarshal([]byte(lines[len(header):]), &files))
+		assert.Equal(t, []output.File{{
+			Path:          "/home/dknite/.tmux.conf",
+			RelativePath:  ".tmux.conf",
+			Mnemonic:      "tmux",
+			HasHistory:    true,
+			Deploy:        "copy",
+			WorkPath:      "/home/dknite/.tmux.conf",
+			CurrentCommit: dotFile.CurrentHistory().UUID(),
+			StorePath:     "/home/dknite/.dotted/store/" + dotFile.RelativePathHash(),
+		}}, files)
+	}
+}
@@ -0,0 +1,115 @@
+package printer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Formats a table can be printed in
+const (
+	BoxFormat      = "table" // Drawn with borders, see TableFprint
+	CSVFormat      = "csv"
+	TSVFormat      = "tsv"
+	MarkdownFormat = "markdown"
+)
+
+// Formats lists the formats a table can be printed in.
+var Formats = []string{BoxFormat, CSVFormat, TSVFormat, MarkdownFormat}
+
+// Fprint prints table to w in format, with options for the box table.
+func Fprint(w io.Writer, table TablePrinter, format string, options Options) error {
+	switch format {
+	case BoxFormat:
+		return TableFprint(w, table, options)
+	case CSVFormat:
+		return CSVFprint(w, table)
+	case TSVFormat:
+		return TSVFprint(w, table)
+	case MarkdownFormat:
+		return MarkdownFprint(w, table)
+	default:
+		return fmt.Errorf("unknown table format %q, expected one of %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// cell is the text of a cell outside the box table, which is the
+// typed value below the header of a TypedTable, so that a bool is
+// true or false rather than a mark.
+func cell(table TablePrinter, row, column int) string {
+	if typed, ok := table.(TypedTable); ok && row != 0 {
+		return formatTyped(typed.TypedValue(row, column))
+	}
+	return stripColor(table.Value(row, column))
+}
+
+func cells(table TablePrinter, row int) []string {
+	values := make([]string, table.ColumnCount())
+	for column := range values {
+		values[column] = cell(table, row, column)
+	}
+	return values
+}
+
+// CSVFprint writes table to w as CSV, with its first row as header.
+func CSVFprint(w io.Writer, table TablePrinter) error {
+	writer := csv.NewWriter(w)
+	for row := 0; row < table.RowCount(); row++ {
+		if err := writer.Write(cells(table, row)); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// TSVFprint writes table to w as tab separated values, escaping
+// tabs, newlines and backslashes in values with a backslash.
+func TSVFprint(w io.Writer, table TablePrinter) error {
+	out := &errWriter{w: w}
+	for row := 0; row < table.RowCount(); row++ {
+		values := cells(table, row)
+		for i, value := range values {
+			values[i] = tsvEscaper.Replace(value)
+		}
+		out.print(strings.Join(values, "\t"), "\n")
+	}
+	return out.err
+}
+
+var markdownEscaper = strings.NewReplacer("|", `\|`, "\n", "<br>")
+
+// MarkdownFprint writes table to w as a Markdown table, with its
+// first row as header and the alignment of its columns.
+func MarkdownFprint(w io.Writer, table TablePrinter) error {
+	out := &errWriter{w: w}
+	printRow := func(values []string) {
+		out.print("|")
+		for _, value := range values {
+			out.print(" ", markdownEscaper.Replace(value), " |")
+		}
+		out.print("\n")
+	}
+	for row := 0; row < table.RowCount(); row++ {
+		printRow(cells(table, row))
+		if row != 0 {
+			continue
+		}
+		rule := make([]string, table.ColumnCount())
+		for column := range rule {
+			switch table.ColumnAlignment(column) {
+			case LeftAlign:
+				rule[column] = ":---"
+			case RightAlign:
+				rule[column] = "---:"
+			default:
+				rule[column] = ":---:"
+			}
+		}
+		printRow(rule)
+	}
+	return out.err
+}
@@ -0,0 +1,155 @@
+package printer
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/term"
+)
+
+// Options controls how tables and trees are drawn.
+type Options struct {
+	// Widest a line may be, in columns. Values which do not fit are
+	// shortened in the middle. Zero means no limit.
+	MaxWidth int
+	// Draw borders and branches with ASCII instead of box-drawing
+	// characters, for terminals without UTF-8
+	ASCII bool
+	// Keep the colours of values, rather than stripping them
+	Color bool
+}
+
+// TerminalOptions fits output to the terminal at out: as wide as the
+// terminal if out is one, ASCII unless the locale is UTF-8, and in
+// colour unless colour is turned off.
+func TerminalOptions(out *os.File) Options {
+	options := Options{ASCII: !isUTF8Locale(), Color: !color.NoColor}
+	if term.IsTerminal(int(out.Fd())) {
+		if width, _, err := term.GetSize(int(out.Fd())); err == nil {
+			options.MaxWidth = width
+		}
+	}
+	return options
+}
+
+// isUTF8Locale reports whether the locale of the environment uses
+// UTF-8, taking an unset locale to be UTF-8 as most terminals are.
+func isUTF8Locale() bool {
+	for _, variable := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale, ok := os.LookupEnv(variable); ok && len(locale) != 0 {
+			locale = strings.ToLower(locale)
+			return strings.Contains(locale, "utf-8") || strings.Contains(locale, "utf8")
+		}
+	}
+	return true
+}
+
+// Characters drawing borders and branches
+type glyphs struct {
+	horizontal, vertical                string
+	topLeft, topRight, topJoin          string
+	bottomLeft, bottomRight, bottomJoin string
+	leftJoin, rightJoin, cross          string
+	branch, lastBranch                  string
+	ellipsis                            string
+}
+
+var unicodeGlyphs = glyphs{
+	horizontal: "─", vertical: "│",
+	topLeft: "┌", topRight: "┐", topJoin: "┬",
+	bottomLeft: "└", bottomRight: "┘", bottomJoin: "┴",
+	leftJoin: "├", rightJoin: "┤", cross: "┼",
+	branch: "├──", lastBranch: "└──",
+	ellipsis: "…",
+}
+
+var asciiGlyphs = glyphs{
+	horizontal: "-", vertical: "|",
+	topLeft: "+", topRight: "+", topJoin: "+",
+	bottomLeft: "+", bottomRight: "+", bottomJoin: "+",
+	leftJoin: "+", rightJoin: "+", cross: "+",
+	branch: "|--", lastBranch: "`--",
+	ellipsis: "...",
+}
+
+func (options Options) glyphs() glyphs {
+	if options.ASCII {
+		return asciiGlyphs
+	}
+	return unicodeGlyphs
+}
+
+// text prepares s to be printed in at most width columns, or without
+// a limit if width is negative. A shortened value loses its colours.
+func (options Options) text(s string, width int) string {
+	if !options.Color {
+		s = stripColor(s)
+	}
+	if width >= 0 && textWidth(s) > width {
+		s = truncateMiddle(stripColor(s), width, options.glyphs().ellipsis)
+	}
+	return s
+}
+
+var colorPattern = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func stripColor(s string) string {
+	return colorPattern.ReplaceAllString(s, "")
+}
+
+// textWidth is the number of columns s takes, ignoring colours. Wide
+// characters, such as CJK and most emoji, take two columns.
+func textWidth(s string) int {
+	return runewidth.StringWidth(stripColor(s))
+}
+
+// truncateMiddle shortens s to width columns by replacing its middle
+// with ellipsis, keeping both ends of paths visible. A wide character
+// which would only half fit is left out.
+func truncateMiddle(s string, width int, ellipsis string) string {
+	if runewidth.StringWidth(s) <= width {
+		return s
+	}
+	ellipsisWidth := runewidth.StringWidth(ellipsis)
+	if width <= ellipsisWidth {
+		return runewidth.Truncate(s, width, "")
+	}
+	kept := width - ellipsisWidth
+	head := runewidth.Truncate(s, kept/2, "")
+	return head + ellipsis + lastColumns(s, kept-runewidth.StringWidth(head))
+}
+
+// lastColumns is the end of s which fits in width columns.
+func lastColumns(s string, width int) string {
+	runes := []rune(s)
+	start := len(runes)
+	for start > 0 {
+		runeWidth := runewidth.RuneWidth(runes[start-1])
+		if runeWidth > width {
+			break
+		}
+		width -= runeWidth
+		start--
+	}
+	return string(runes[start:])
+}
+
+// errWriter remembers the first error writing to w, so that drawing
+// can go on without checking every write.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (writer *errWriter) print(parts ...string) {
+	for _, part := range parts {
+		if writer.err != nil {
+			return
+		}
+		_, writer.err = io.WriteString(writer.w, part)
+	}
+}
@@ -1,9 +1,9 @@
 package printer
 
 import (
-	"fmt"
+	"io"
+	"os"
 	"strings"
-	"unicode/utf8"
 )
 
 type ColumnAlignment = int
@@ -22,69 +22,91 @@ type TablePrinter interface {
 	ColumnAlignment(column int) ColumnAlignment
 }
 
+// minColumnWidth is the narrowest a column is shrunk to fit MaxWidth.
+const minColumnWidth = 5
+
+// TablePrint prints table to stdout, fitted to the terminal.
 func TablePrint(table TablePrinter) {
-	columnWidths := make([]int, table.ColumnCount())
+	TableFprint(os.Stdout, table, TerminalOptions(os.Stdout))
+}
+
+// TableFprint draws table to w with options. The widest columns are
+// shrunk until the table fits MaxWidth, shortening their values.
+func TableFprint(w io.Writer, table TablePrinter, options Options) error {
 	rows := table.RowCount()
 	cols := table.ColumnCount()
 	ipad := table.Ipad()
+	columnWidths := make([]int, cols)
 	for i := 0; i < cols; i++ {
 		for j := 0; j < rows; j++ {
-			width := utf8.RuneCountInString(table.Value(j, i))
+			width := textWidth(table.Value(j, i))
 			if columnWidths[i] < width {
 				columnWidths[i] = width
 			}
 		}
 	}
+	if options.MaxWidth > 0 {
+		fitColumns(columnWidths, options.MaxWidth-(cols+1)-2*ipad*cols)
+	}
+
+	glyphs := options.glyphs()
+	out := &errWriter{w: w}
+	border := func(left, join, right string) {
+		out.print(left)
+		for col := 0; col < cols; col++ {
+			out.print(strings.Repeat(glyphs.horizontal, columnWidths[col]+2*ipad))
+			if col != cols-1 {
+				out.print(join)
+			}
+		}
+		out.print(right, "\n")
+	}
 	for row := 0; row < rows; row++ {
 		if row == 0 {
-			fmt.Print("\u250C")
-			for col := 0; col < cols; col++ {
-				fmt.Print(rule(columnWidths[col] + 2*ipad))
-				if col != cols-1 {
-					fmt.Print("\u252C")
-				}
-			}
-			fmt.Println("\u2510")
+			border(glyphs.topLeft, glyphs.topJoin, glyphs.topRight)
 		} else {
-			fmt.Print("\u251C")
-			for col := 0; col < cols; col++ {
-				fmt.Print(rule(columnWidths[col] + 2*ipad))
-				if col != cols-1 {
-					fmt.Print("\u253C")
-				}
-			}
-			fmt.Println("\u2524")
+			border(glyphs.leftJoin, glyphs.cross, glyphs.rightJoin)
 		}
 		for col := 0; col < cols; col++ {
-			fmt.Printf("\u2502%s",
-				pad(table.Value(row, col),
-					columnWidths[col], ipad,
-					table.ColumnAlignment(col)))
+			value := options.text(table.Value(row, col), columnWidths[col])
+			out.print(glyphs.vertical, pad(value, columnWidths[col], ipad, table.ColumnAlignment(col)))
 		}
-		fmt.Println("\u2502")
+		out.print(glyphs.vertical, "\n")
+	}
+	border(glyphs.bottomLeft, glyphs.bottomJoin, glyphs.bottomRight)
+	return out.err
+}
+
+// fitColumns narrows the widest of columnWidths, one column at a
+// time, until they add up to at most width or cannot shrink further.
+func fitColumns(columnWidths []int, width int) {
+	total := 0
+	for _, columnWidth := range columnWidths {
+		total += columnWidth
 	}
-	fmt.Print("\u2514")
-	for col := 0; col < cols; col++ {
-		fmt.Print(rule(columnWidths[col] + 2*ipad))
-		if col != cols-1 {
-			fmt.Print("\u2534")
+	for total > width {
+		widest := 0
+		for i, columnWidth := range columnWidths {
+			if columnWidth > columnWidths[widest] {
+				widest = i
+			}
+		}
+		if columnWidths[widest] <= minColumnWidth {
+			return
 		}
+		columnWidths[widest] -= 1
+		total -= 1
 	}
-	fmt.Println("\u2518")
 }
 
 func spacer(width int) string {
 	return strings.Repeat(" ", width)
 }
 
-func rule(width int) string {
-	return strings.Repeat("\u2500", width)
-}
-
 func pad(s string, width, ipad int, alignment ColumnAlignment) string {
 	var res string
 
-	rest := width - utf8.RuneCountInString(s)
+	rest := width - textWidth(s)
 	switch alignment {
 	case RightAlign:
 		res = spacer(rest) + s
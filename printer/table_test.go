@@ -0,0 +1,113 @@
+package printer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+type testTable [][]string
+
+func (table testTable) RowCount() int {
+	return len(table)
+}
+
+func (table testTable) ColumnCount() int {
+	return len(table[0])
+}
+
+func (table testTable) Value(row, column int) string {
+	return table[row][column]
+}
+
+func (table testTable) Ipad() int {
+	return 1
+}
+
+func (table testTable) ColumnAlignment(column int) ColumnAlignment {
+	if column == 0 {
+		return LeftAlign
+	}
+	return CenterAlign
+}
+
+var table = testTable{
+	{"Path", "Mnemonic"},
+	{"/home/dknite/.config/alacritty/alacritty.yml", "alacritty"},
+	{"/home/dknite/.vimrc", "vim"},
+}
+
+func TestTableFprint(t *testing.T) {
+	var out bytes.Buffer
+	err := TableFprint(&out, table, Options{})
+	assert.Nil(t, err)
+	assert.Equal(t, `┌──────────────────────────────────────────────┬───────────┐
+│ Path                                         │ Mnemonic  │
+├──────────────────────────────────────────────┼───────────┤
+│ /home/dknite/.config/alacritty/alacritty.yml │ alacritty │
+├──────────────────────────────────────────────┼───────────┤
+│ /home/dknite/.vimrc                          │    vim    │
+└──────────────────────────────────────────────┴───────────┘
+`, out.String())
+
+	out.Reset()
+	err = TableFprint(&out, table, Options{MaxWidth: 40, ASCII: true})
+	assert.Nil(t, err)
+	assert.Equal(t, `+--------------------------+-----------+
+| Path                     | Mnemonic  |
++--------------------------+-----------+
+| /home/dkni...acritty.yml | alacritty |
++--------------------------+-----------+
+| /home/dknite/.vimrc      |    vim    |
++--------------------------+-----------+
+`, out.String())
+}
+
+func TestTableFprintColor(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+	colored := testTable{{color.GreenString("vim"), "x"}}
+
+	var out bytes.Buffer
+	err := TableFprint(&out, colored, Options{Color: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "┌─────┬───┐\n│ \x1b[32mvim\x1b[0m │ x │\n└─────┴───┘\n", out.String())
+
+	out.Reset()
+	err = TableFprint(&out, colored, Options{})
+	assert.Nil(t, err)
+	assert.Equal(t, "┌─────┬───┐\n│ vim │ x │\n└─────┴───┘\n", out.String())
+}
+
+func TestTruncateMiddle(t *testing.T) {
+	assert.Equal(t, "/home/dknite", truncateMiddle("/home/dknite", 12, "…"))
+	assert.Equal(t, "/hom…knite", truncateMiddle("/home/dknite", 10, "…"))
+	assert.Equal(t, "/h...ite", truncateMiddle("/home/dknite", 8, "..."))
+	assert.Equal(t, "/h", truncateMiddle("/home/dknite", 2, "..."))
+	// Wide characters take two columns, and are left out if only one
+	// is left for them
+	assert.Equal(t, "/设…件.md", truncateMiddle("/设置/文件.md", 9, "…"))
+	assert.Equal(t, "/设….md", truncateMiddle("/设置/文件.md", 8, "…"))
+}
+
+func TestTableFprintWide(t *testing.T) {
+	wide := testTable{
+		{"Path", "Mnemonic"},
+		{"/home/dknite/笔记.md", "笔记"},
+		{"/home/dknite/.vimrc", "vim"},
+	}
+	var out bytes.Buffer
+	err := TableFprint(&out, wide, Options{})
+	assert.Nil(t, err)
+	assert.Equal(t, `┌──────────────────────┬──────────┐
+│ Path                 │ Mnemonic │
+├──────────────────────┼──────────┤
+│ /home/dknite/笔记.md │   笔记   │
+├──────────────────────┼──────────┤
+│ /home/dknite/.vimrc  │   vim    │
+└──────────────────────┴──────────┘
+`, out.String())
+}
@@ -1,7 +1,13 @@
 package printer
 
-import "fmt"
+import (
+	"io"
+	"os"
+	"strings"
+)
 
+// TreeNode is a node of a tree. Render gives its line, which ends
+// with a newline.
 type TreeNode interface {
 	Render() string
 	IsLeaf() bool
@@ -16,21 +22,36 @@ const (
 	other
 )
 
-func treePrintRecursive(node TreeNode, ops []operation) {
+func treePrintRecursive(out *errWriter, node TreeNode, ops []operation, options Options) {
+	glyphs := options.glyphs()
+	indent := 0
 	for _, op := range ops[:len(ops)-1] {
 		if op == other {
-			fmt.Print("\u2502  ")
+			out.print(glyphs.vertical, "  ")
+			indent += 3
 		} else if op == last {
-			fmt.Print("   ")
+			out.print("   ")
+			indent += 3
 		}
 	}
 	lastOp := ops[len(ops)-1]
 	if lastOp == last {
-		fmt.Print("\u2514\u2500\u2500")
+		out.print(glyphs.lastBranch)
+		indent += 3
 	} else if lastOp == other {
-		fmt.Print("\u251C\u2500\u2500")
+		out.print(glyphs.branch)
+		indent += 3
+	}
+	line := node.Render()
+	text := strings.TrimSuffix(line, "\n")
+	width := -1
+	if options.MaxWidth > 0 {
+		width = options.MaxWidth - indent
+		if width < minColumnWidth {
+			width = minColumnWidth
+		}
 	}
-	fmt.Print(node.Render())
+	out.print(options.text(text, width), line[len(text):])
 	children := node.Children()
 	for i, child := range children {
 		var newOp operation
@@ -40,11 +61,21 @@ func treePrintRecursive(node TreeNode, ops []operation) {
 			newOp = other
 		}
 		ops = append(ops, newOp)
-		treePrintRecursive(child, ops)
+		treePrintRecursive(out, child, ops, options)
 		ops = ops[:len(ops)-1]
 	}
 }
 
+// TreePrint prints the tree under node to stdout, fitted to the
+// terminal.
 func TreePrint(node TreeNode) {
-	treePrintRecursive(node, []operation{root})
+	TreeFprint(os.Stdout, node, TerminalOptions(os.Stdout))
+}
+
+// TreeFprint draws the tree under node to w with options. Lines
+// longer than MaxWidth are shortened in the middle.
+func TreeFprint(w io.Writer, node TreeNode, options Options) error {
+	out := &errWriter{w: w}
+	treePrintRecursive(out, node, []operation{root}, options)
+	return out.err
 }
@@ -0,0 +1,49 @@
+package printer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testNode struct {
+	name     string
+	children []TreeNode
+}
+
+func (node testNode) Render() string {
+	return node.name + "\n"
+}
+
+func (node testNode) IsLeaf() bool {
+	return len(node.children) == 0
+}
+
+func (node testNode) Children() []TreeNode {
+	return node.children
+}
+
+var tree = testNode{"root", []TreeNode{
+	testNode{"first", []TreeNode{testNode{"first-child", nil}}},
+	testNode{"second-with-a-long-name", nil},
+}}
+
+func TestTreeFprint(t *testing.T) {
+	var out bytes.Buffer
+	err := TreeFprint(&out, tree, Options{})
+	assert.Nil(t, err)
+	assert.Equal(t, `root
+├──first
+│  └──first-child
+└──second-with-a-long-name
+`, out.String())
+
+	out.Reset()
+	err = TreeFprint(&out, tree, Options{MaxWidth: 15, ASCII: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "root\n"+
+		"|--first\n"+
+		"|  `--fir...ild\n"+
+		"`--seco...-name\n", out.String())
+}
@@ -0,0 +1,155 @@
+package printer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TypedTable is a table whose first row is its header, and whose
+// columns have names to sort and filter by. TypedValue gives the
+// value of a cell below the header as a string, bool, int, float64
+// or time.Time, which Value may show differently.
+type TypedTable interface {
+	TablePrinter
+	ColumnName(column int) string
+	TypedValue(row, column int) interface{}
+}
+
+// Filter keeps the rows whose value in Column is Value. Strings match
+// Value as a glob, where * is any text including / and ? is any
+// character, and other values match its parsed form.
+type Filter struct {
+	Column string
+	Value  string
+}
+
+// ParseFilter parses a filter written as column=value.
+func ParseFilter(filter string) (Filter, error) {
+	parts := strings.SplitN(filter, "=", 2)
+	if len(parts) != 2 || len(parts[0]) == 0 {
+		return Filter{}, fmt.Errorf("invalid filter %q, expected column=value", filter)
+	}
+	return Filter{Column: parts[0], Value: parts[1]}, nil
+}
+
+// ColumnNamed finds the column of table called name.
+func ColumnNamed(table TypedTable, name string) (int, error) {
+	var names []string
+	for column := 0; column < table.ColumnCount(); column++ {
+		if table.ColumnName(column) == name {
+			return column, nil
+		}
+		names = append(names, table.ColumnName(column))
+	}
+	return -1, fmt.Errorf("unknown column %q, expected one of %s", name, strings.Join(names, ", "))
+}
+
+// Select picks the rows below the header of table which match every
+// filter, sorted by the column named sortBy unless it is empty. The
+// sort is stable, so rows with equal values keep their order.
+func Select(table TypedTable, filters []Filter, sortBy string, reverse bool) ([]int, error) {
+	filterColumns := make([]int, len(filters))
+	for i, filter := range filters {
+		column, err := ColumnNamed(table, filter.Column)
+		if err != nil {
+			return nil, err
+		}
+		filterColumns[i] = column
+	}
+	rows := []int{}
+	for row := 1; row < table.RowCount(); row++ {
+		matched := true
+		for i, filter := range filters {
+			ok, err := matches(table.TypedValue(row, filterColumns[i]), filter.Value)
+			if err != nil {
+				return nil, err
+			}
+			matched = matched && ok
+		}
+		if matched {
+			rows = append(rows, row)
+		}
+	}
+	if len(sortBy) == 0 {
+		return rows, nil
+	}
+	column, err := ColumnNamed(table, sortBy)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		first := table.TypedValue(rows[i], column)
+		second := table.TypedValue(rows[j], column)
+		if reverse {
+			return less(second, first)
+		}
+		return less(first, second)
+	})
+	return rows, nil
+}
+
+func matches(value interface{}, pattern string) (bool, error) {
+	switch value := value.(type) {
+	case string:
+		return globPattern(pattern).MatchString(value), nil
+	case bool:
+		parsed, err := strconv.ParseBool(pattern)
+		if err != nil {
+			return false, fmt.Errorf("invalid filter value %q, expected true or false", pattern)
+		}
+		return value == parsed, nil
+	default:
+		return formatTyped(value) == pattern, nil
+	}
+}
+
+func globPattern(glob string) *regexp.Regexp {
+	quoted := regexp.QuoteMeta(glob)
+	quoted = strings.ReplaceAll(quoted, `\*`, ".*")
+	quoted = strings.ReplaceAll(quoted, `\?`, ".")
+	return regexp.MustCompile("^" + quoted + "$")
+}
+
+// less orders values of the same type, and values of differing types
+// by their text.
+func less(first, second interface{}) bool {
+	switch first := first.(type) {
+	case string:
+		if second, ok := second.(string); ok {
+			return first < second
+		}
+	case bool:
+		if second, ok := second.(bool); ok {
+			return !first && second
+		}
+	case int:
+		if second, ok := second.(int); ok {
+			return first < second
+		}
+	case float64:
+		if second, ok := second.(float64); ok {
+			return first < second
+		}
+	case time.Time:
+		if second, ok := second.(time.Time); ok {
+			return first.Before(second)
+		}
+	}
+	return formatTyped(first) < formatTyped(second)
+}
+
+// formatTyped writes a typed value as text for CSV and TSV.
+func formatTyped(value interface{}) string {
+	switch value := value.(type) {
+	case time.Time:
+		return value.Format(time.RFC3339)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,127 @@
+package printer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fileTable has a header and rows of path, mnemonic and whether the
+// file has history.
+type fileTable [][3]interface{}
+
+func (table fileTable) RowCount() int {
+	return len(table)
+}
+
+func (table fileTable) ColumnCount() int {
+	return 3
+}
+
+func (table fileTable) Value(row, column int) string {
+	if value, ok := table[row][column].(bool); ok {
+		if value {
+			return "🗸"
+		}
+		return "✗"
+	}
+	return table[row][column].(string)
+}
+
+func (table fileTable) Ipad() int {
+	return 1
+}
+
+func (table fileTable) ColumnAlignment(column int) ColumnAlignment {
+	if column == 2 {
+		return CenterAlign
+	}
+	return LeftAlign
+}
+
+func (table fileTable) ColumnName(column int) string {
+	return [3]string{"path", "mnemonic", "history"}[column]
+}
+
+func (table fileTable) TypedValue(row, column int) interface{} {
+	return table[row][column]
+}
+
+var files = fileTable{
+	{"Path", "Mnemonic", "Has History"},
+	{"/home/dknite/.vimrc", "vim", true},
+	{"/home/dknite/.config/alacritty/alacritty.yml", "alacritty", false},
+	{"/home/dknite/.tmux.conf", "tmux", true},
+}
+
+func TestSelect(t *testing.T) {
+	rows, err := Select(files, nil, "", false)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3}, rows)
+
+	rows, err = Select(files, nil, "mnemonic", false)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2, 3, 1}, rows)
+
+	rows, err = Select(files, []Filter{{"history", "true"}}, "path", true)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 3}, rows)
+
+	rows, err = Select(files, []Filter{{"path", "*.config/*"}}, "", false)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2}, rows)
+
+	rows, err = Select(files, []Filter{{"history", "false"}, {"mnemonic", "v?m"}}, "", false)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{}, rows)
+
+	_, err = Select(files, nil, "size", false)
+	assert.EqualError(t, err, `unknown column "size", expected one of path, mnemonic, history`)
+	_, err = Select(files, []Filter{{"history", "maybe"}}, "", false)
+	assert.EqualError(t, err, `invalid filter value "maybe", expected true or false`)
+}
+
+func TestParseFilter(t *testing.T) {
+	filter, err := ParseFilter("path=*/.config/*=x")
+	assert.Nil(t, err)
+	assert.Equal(t, Filter{"path", "*/.config/*=x"}, filter)
+	_, err = ParseFilter("history")
+	assert.EqualError(t, err, `invalid filter "history", expected column=value`)
+	_, err = ParseFilter("=true")
+	assert.NotNil(t, err)
+}
+
+func TestEncodings(t *testing.T) {
+	var out bytes.Buffer
+	assert.Nil(t, Fprint(&out, files[:3], CSVFormat, Options{}))
+	assert.Equal(t, `Path,Mnemonic,Has History
+/home/dknite/.vimrc,vim,true
+/home/dknite/.config/alacritty/alacritty.yml,alacritty,false
+`, out.String())
+
+	out.Reset()
+	assert.Nil(t, Fprint(&out, files[:2], TSVFormat, Options{}))
+	assert.Equal(t, "Path\tMnemonic\tHas History\n/home/dknite/.vimrc\tvim\ttrue\n", out.String())
+
+	out.Reset()
+	assert.Nil(t, Fprint(&out, files[:2], MarkdownFormat, Options{}))
+	assert.Equal(t, `| Path | Mnemonic | Has History |
+| :--- | :--- | :---: |
+| /home/dknite/.vimrc | vim | true |
+`, out.String())
+
+	escaped := testTable{{"A", "B"}, {"x|y", "tab\there\nnew"}}
+	out.Reset()
+	assert.Nil(t, Fprint(&out, escaped, MarkdownFormat, Options{}))
+	assert.Equal(t, "| A | B |\n| :--- | :---: |\n| x\\|y | tab\there<br>new |\n", out.String())
+	out.Reset()
+	assert.Nil(t, Fprint(&out, escaped, TSVFormat, Options{}))
+	assert.Equal(t, "A\tB\nx|y\ttab\\there\\nnew\n", out.String())
+	out.Reset()
+	assert.Nil(t, Fprint(&out, escaped, CSVFormat, Options{}))
+	assert.Equal(t, "A,B\nx|y,\"tab\there\nnew\"\n", out.String())
+
+	assert.EqualError(t, Fprint(&out, files, "html", Options{}),
+		`unknown table format "html", expected one of table, csv, tsv, markdown`)
+}
@@ -0,0 +1,69 @@
+package render
+
+import (
+	"bytes"
+	"os"
+	"os/user"
+	"runtime"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// Variables are the values from the variables section of the
+// config, available to templates as .Vars.
+var Variables map[string]interface{}
+
+// Facts describe the host a template is rendered on.
+type Facts struct {
+	Hostname string
+	OS       string // As in GOOS, such as linux or darwin
+	Arch     string // As in GOARCH, such as amd64 or arm64
+	User     string
+	Home     string
+}
+
+// Data is what templates are executed with.
+type Data struct {
+	Facts
+	Vars map[string]interface{}
+}
+
+// HostFacts finds the facts of this host. Facts which cannot be
+// found are left empty.
+func HostFacts() Facts {
+	facts := Facts{
+		OS:   runtime.GOOS,
+		Arch: runtime.GOARCH,
+	}
+	facts.Hostname, _ = os.Hostname()
+	facts.Home, _ = os.UserHomeDir()
+	if current, err := user.Current(); err == nil {
+		facts.User = current.Username
+	}
+	return facts
+}
+
+var funcs = template.FuncMap{
+	"env": os.Getenv,
+}
+
+// Render executes the template source, named name in errors, with
+// the facts of this host and Variables. Using a variable which is
+// not defined is an error.
+func Render(name, source string) (string, error) {
+	return RenderWith(name, source, Data{HostFacts(), Variables})
+}
+
+// RenderWith is like Render, with the given data.
+func RenderWith(name, source string, data Data) (string, error) {
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse template")
+	}
+	var out bytes.Buffer
+	if err = tmpl.Execute(&out, data); err != nil {
+		return "", errors.Wrap(err, "failed to render template")
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,36 @@
+package render
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	assert := assert.New(t)
+	source := `font:
+  size: {{ if eq .Hostname "laptop" }}9{{ else }}{{ .Vars.fontSize }}{{ end }}
+shell: {{ .Vars.shell.program }}
+`
+	vars := map[string]interface{}{
+		"fontSize": 12,
+		"shell":    map[interface{}]interface{}{"program": "fish"},
+	}
+	out, err := RenderWith("alacritty.yml", source, Data{Facts{Hostname: "laptop"}, vars})
+	assert.Nil(err)
+	assert.Equal("font:\n  size: 9\nshell: fish\n", out)
+	out, err = RenderWith("alacritty.yml", source, Data{Facts{Hostname: "desktop"}, vars})
+	assert.Nil(err)
+	assert.Equal("font:\n  size: 12\nshell: fish\n", out)
+
+	_, err = RenderWith("alacritty.yml", "{{ .Vars.missing }}", Data{Vars: vars})
+	assert.NotNil(err)
+	_, err = RenderWith("alacritty.yml", "{{ if }}", Data{})
+	assert.NotNil(err)
+
+	Variables = vars
+	out, err = Render("os", "{{ .OS }}/{{ .Arch }} {{ .Vars.fontSize }}")
+	assert.Nil(err)
+	assert.Equal(runtime.GOOS+"/"+runtime.GOARCH+" 12", out)
+}
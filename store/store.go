@@ -10,6 +10,7 @@ import (
 	"github.com/RedDocMD/dotted/file"
 	"github.com/RedDocMD/dotted/fs"
 	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
 )
 
 var Fs = fs.OsFs
@@ -17,101 +18,433 @@ var Afs = fs.OsAfs
 
 type Store struct {
 	files []*file.DotFile
-	path  string
-	name  string
+	// Paths of the dot-files in the store whose entries do not
+	// apply to this host, which are kept but not loaded
+	inactive []string
+	// Paths of the dot-files whose entries left the config, whose
+	// data is kept until purged
+	archived      []string
+	newlyArchived []string
+	// Paths of the dot-files whose history was frozen while loading
+	newlyFrozen []string
+	// Entries in the config by path, which are saved with the
+	// dot-files so that archived files are restored with them
+	entries map[string]Entries
+	path    string
+	name    string
 }
 
+// Entries are the entries of a dot-file in the config, for any host.
+type Entries struct {
+	WithHistory    []config.FileEntry `yaml:"withHistory,omitempty"`
+	WithoutHistory []config.FileEntry `yaml:"withoutHistory,omitempty"`
+}
+
+// File in the directory of a dot-file holding its Entries
+const entriesFile = "entries"
+
+// Host is the host whose entries are loaded from the config.
+var Host = config.CurrentHost
+
 func (store *Store) Files() []*file.DotFile {
 	return store.files
 }
 
+// AddFile adds a dot-file which is not in the store yet.
+func (store *Store) AddFile(dotFile *file.DotFile) error {
+	for _, existing := range store.files {
+		if existing.Path() == dotFile.Path() {
+			return fmt.Errorf("failed to add %s: already in store", dotFile.Path())
+		}
+	}
+	store.files = append(store.files, dotFile)
+	return nil
+}
+
+// RemoveFile removes a dot-file from the store.
+func (store *Store) RemoveFile(dotFile *file.DotFile) {
+	for i, existing := range store.files {
+		if existing == dotFile {
+			store.files = append(store.files[:i], store.files[i+1:]...)
+			return
+		}
+	}
+}
+
+func (store *Store) Path() string {
+	return store.path
+}
+
+// Archived returns the paths, relative to $HOME, of the archived
+// dot-files.
+func (store *Store) Archived() []string {
+	return store.archived
+}
+
+// NewlyArchived returns the paths of the dot-files which were
+// archived while loading the store, as their entries were gone.
+func (store *Store) NewlyArchived() []string {
+	return store.newlyArchived
+}
+
+// NewlyFrozen returns the paths of the dot-files whose history was
+// frozen while loading the store, as they moved to withoutHistory.
+func (store *Store) NewlyFrozen() []string {
+	return store.newlyFrozen
+}
+
+// LoadArchived loads the archived dot-file at path, relative to
+// $HOME, without restoring it.
+func (store *Store) LoadArchived(path string) (*file.DotFile, error) {
+	if !store.isArchived(path) {
+		return nil, fmt.Errorf("failed to load %s: not archived", path)
+	}
+	basePath := Fs.Join(store.path, storePath(path))
+	dotFile, err := file.LoadDotFileFromDisk(basePath, dotFilePath(path))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to load %s", path)
+	}
+	return dotFile, nil
+}
+
+// Restore brings back the archived dot-file at path, relative to
+// $HOME, with its history, and returns the entries it had in the
+// config, which are empty if they were not saved. Its entries must
+// be added to the config for it to stay in the store.
+func (store *Store) Restore(path string) (*file.DotFile, Entries, error) {
+	var entries Entries
+	dotFile, err := store.LoadArchived(path)
+	if err != nil {
+		return nil, entries, err
+	}
+	entriesBytes, err := Afs.ReadFile(Fs.Join(store.path, storePath(path), entriesFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, entries, errors.Wrapf(err, "failed to restore %s", path)
+	}
+	if err = yaml.Unmarshal(entriesBytes, &entries); err != nil {
+		return nil, entries, errors.Wrapf(err, "failed to restore %s", path)
+	}
+	if err = store.AddFile(dotFile); err != nil {
+		return nil, entries, err
+	}
+	store.archived = removePath(store.archived, path)
+	return dotFile, entries, nil
+}
+
+// Purge permanently deletes the data of the archived dot-file at
+// path, relative to $HOME.
+func (store *Store) Purge(path string) error {
+	if !store.isArchived(path) {
+		return fmt.Errorf("failed to purge %s: not archived", path)
+	}
+	if err := Afs.RemoveAll(Fs.Join(store.path, storePath(path))); err != nil {
+		return errors.Wrapf(err, "failed to purge %s", path)
+	}
+	store.archived = removePath(store.archived, path)
+	return nil
+}
+
+func (store *Store) isArchived(path string) bool {
+	for _, archived := range store.archived {
+		if archived == path {
+			return true
+		}
+	}
+	return false
+}
+
+func removePath(paths []string, path string) []string {
+	var kept []string
+	for _, existing := range paths {
+		if existing != path {
+			kept = append(kept, existing)
+		}
+	}
+	return kept
+}
+
 func dotFilePath(path string) string {
 	return Fs.Join(Fs.UserHomeDir(), path)
 }
 
+// WorkTreeDir is the directory in the store holding the current
+// version of the files deployed as symlinks, laid out as in $HOME.
+const WorkTreeDir = "tree"
+
+func setWorkPath(dotFile *file.DotFile, location, path, template, deploy string) {
+	if len(template) != 0 {
+		dotFile.SetTemplate(dotFilePath(template))
+	} else if deploy == config.SymlinkDeploy {
+		dotFile.SetWorkPath(Fs.Join(location, WorkTreeDir, path))
+	}
+}
+
+// newDotFile creates the dot-file of an entry which is not in the
+// store yet, from its template if it has one.
+func newDotFile(entry config.FileEntry, hasHistory bool) (*file.DotFile, error) {
+	if len(entry.Template) == 0 {
+		return file.NewDotFile(dotFilePath(entry.Path), entry.Mnemonic, hasHistory)
+	}
+	buf, err := Afs.ReadFile(dotFilePath(entry.Template))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read template")
+	}
+	return file.NewDotFileWithContent(dotFilePath(entry.Path), entry.Mnemonic, string(buf), hasHistory)
+}
+
 func LoadFromDisk(config *config.Config) (*Store, error) {
 	pathsDone := make(map[string]struct{})
 	var dotFiles []*file.DotFile
+	var inactive []string
+	withHistory, withoutHistory := config.ActiveEntries(Host())
 
-	pathFilePath := Fs.Join(config.StoreLocation, "paths")
-	pathFileBytes, err := Afs.ReadFile(pathFilePath)
-	if err == nil || os.IsNotExist(err) {
-		paths := strings.Split(string(pathFileBytes), "\n")
-		if len(paths[len(paths)-1]) == 0 {
-			paths = paths[:len(paths)-1]
-		}
-		for _, path := range paths {
-			basePath := Fs.Join(config.StoreLocation, storePath(path))
-			dotFile, err := file.LoadDotFileFromDisk(basePath, dotFilePath(path))
-			if err != nil && !errors.Is(err, file.BasePathNotFound) {
-				return nil, errors.Wrap(err, "failed to load store")
-			}
-			var fileInStore, fileInConfig, fileHasHistory bool
-			fileInStore = err == nil
-			if containsPath(path, config.WithHistory) {
-				fileInConfig = true
-				fileHasHistory = true
-			} else if containsPath(path, config.WithoutHistory) {
-				fileInConfig = true
-				fileHasHistory = false
-			}
-			if fileInConfig && fileInStore {
-				if dotFile.HasHistory() && !fileHasHistory {
-					dotFile.RemoveHistory()
-				} else if !dotFile.HasHistory() && fileHasHistory {
-					dotFile.InitHistory()
-				}
-				dotFiles = append(dotFiles, dotFile)
-				pathsDone[path] = struct{}{}
-			} else if !fileInConfig && fileInStore {
-				err = Afs.RemoveAll(basePath)
-				if err != nil {
-					return nil, errors.Wrap(err, "failed to load store")
-				}
-			} else if !fileInConfig && !fileInStore {
-				return nil, errors.New(fmt.Sprintf("failed to load store: store in inconsistent state: directory for %s listed but not found", path))
+	paths, err := readPaths(config.StoreLocation)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load store")
+	}
+	archivedPaths, err := readList(Fs.Join(config.StoreLocation, archivedFile))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load store")
+	}
+	var archived, newlyArchived, newlyFrozen []string
+	for _, path := range archivedPaths {
+		// An archived file is back once its entry is in the config again
+		if _, ok := entryWithPath(path, config.WithHistory); ok {
+			paths = append(paths, path)
+		} else if _, ok := entryWithPath(path, config.WithoutHistory); ok {
+			paths = append(paths, path)
+		} else {
+			archived = append(archived, path)
+		}
+	}
+	for _, path := range paths {
+		if isInactive(path, config, withHistory, withoutHistory) {
+			// Kept as it is for the hosts the entry applies to
+			inactive = append(inactive, path)
+			pathsDone[path] = struct{}{}
+			continue
+		}
+		basePath := Fs.Join(config.StoreLocation, storePath(path))
+		dotFile, err := file.LoadDotFileFromDisk(basePath, dotFilePath(path))
+		if err != nil && !errors.Is(err, file.BasePathNotFound) {
+			return nil, errors.Wrap(err, "failed to load store")
+		}
+		var fileInStore, fileInConfig, fileHasHistory, fileEncrypted bool
+		var fileDeploy, fileTemplate string
+		fileInStore = err == nil
+		if withEntry, ok := entryWithPath(path, withHistory); ok {
+			fileInConfig = true
+			fileHasHistory = true
+			fileEncrypted = withEntry.Encrypted
+			fileDeploy = config.DeployMode(withEntry)
+			fileTemplate = withEntry.Template
+		} else if withoutEntry, ok := entryWithPath(path, withoutHistory); ok {
+			fileInConfig = true
+			fileHasHistory = false
+			fileEncrypted = withoutEntry.Encrypted
+			fileDeploy = config.DeployMode(withoutEntry)
+			fileTemplate = withoutEntry.Template
+		}
+		if fileInConfig && fileInStore {
+			dotFile.SetEncrypted(fileEncrypted)
+			setWorkPath(dotFile, config.StoreLocation, path, fileTemplate, fileDeploy)
+			if dotFile.HasHistory() && !fileHasHistory {
+				dotFile.RemoveHistory()
+				newlyFrozen = append(newlyFrozen, path)
+			} else if !dotFile.HasHistory() && fileHasHistory {
+				dotFile.InitHistory()
 			}
+			dotFiles = append(dotFiles, dotFile)
+			pathsDone[path] = struct{}{}
+		} else if !fileInConfig && fileInStore {
+			archived = append(archived, path)
+			newlyArchived = append(newlyArchived, path)
+			pathsDone[path] = struct{}{}
+		} else if !fileInConfig && !fileInStore {
+			return nil, errors.New(fmt.Sprintf("failed to load store: store in inconsistent state: directory for %s listed but not found", path))
 		}
-	} else if err != nil {
-		return nil, errors.Wrap(err, "failed to load store")
 	}
-	for _, entry := range config.WithHistory {
+	for _, entry := range withHistory {
 		path := entry.Path
 		if _, ok := pathsDone[path]; !ok {
-			dotFile, err := file.NewDotFile(dotFilePath(path), entry.Mnemonic, true)
+			dotFile, err := newDotFile(entry, true)
 			if err != nil {
 				return nil, errors.Wrap(err, "failed to load store")
 			}
+			dotFile.SetEncrypted(entry.Encrypted)
+			setWorkPath(dotFile, config.StoreLocation, path, entry.Template, config.DeployMode(entry))
 			dotFiles = append(dotFiles, dotFile)
 		}
 	}
-	for _, entry := range config.WithoutHistory {
+	for _, entry := range withoutHistory {
 		path := entry.Path
 		if _, ok := pathsDone[path]; !ok {
-			dotFile, err := file.NewDotFile(dotFilePath(path), entry.Mnemonic, false)
+			dotFile, err := newDotFile(entry, false)
 			if err != nil {
 				return nil, errors.Wrap(err, "failed to load store")
 			}
+			dotFile.SetEncrypted(entry.Encrypted)
+			setWorkPath(dotFile, config.StoreLocation, path, entry.Template, config.DeployMode(entry))
 			dotFiles = append(dotFiles, dotFile)
 		}
 	}
+	entries := make(map[string]Entries)
+	for _, entry := range config.WithHistory {
+		pathEntries := entries[entry.Path]
+		pathEntries.WithHistory = append(pathEntries.WithHistory, entry)
+		entries[entry.Path] = pathEntries
+	}
+	for _, entry := range config.WithoutHistory {
+		pathEntries := entries[entry.Path]
+		pathEntries.WithoutHistory = append(pathEntries.WithoutHistory, entry)
+		entries[entry.Path] = pathEntries
+	}
 	store := &Store{
-		files: dotFiles,
-		path:  config.StoreLocation,
-		name:  config.Name,
+		files:         dotFiles,
+		inactive:      inactive,
+		archived:      archived,
+		newlyArchived: newlyArchived,
+		newlyFrozen:   newlyFrozen,
+		entries:       entries,
+		path:          config.StoreLocation,
+		name:          config.Name,
 	}
 	return store, nil
 }
 
-func containsPath(path string, entries []config.FileEntry) bool {
-	for _, entry := range entries {
-		if entry.Path == path {
+// Files in the store listing the paths of dot-files
+const (
+	pathsFile    = "paths"
+	archivedFile = "archived"
+)
+
+// Manifests are the files of a store listing its dot-files.
+var Manifests = []string{pathsFile, archivedFile}
+
+// MergeManifests lists in the store at location the dot-files of the
+// store at other as well as its own, so that it describes both once
+// their directories are copied into it. A path listed by either
+// store is only archived if neither lists it as a dot-file.
+func MergeManifests(location, other string) error {
+	var lists [2][2][]string
+	for i, storePath := range []string{location, other} {
+		for j, name := range Manifests {
+			list, err := readList(Fs.Join(storePath, name))
+			if err != nil {
+				return errors.Wrap(err, "failed to merge stores")
+			}
+			lists[i][j] = list
+		}
+	}
+	paths := union(lists[0][0], lists[1][0])
+	var archived []string
+	for _, path := range union(lists[0][1], lists[1][1]) {
+		if !contains(paths, path) {
+			archived = append(archived, path)
+		}
+	}
+	if err := writeList(Fs.Join(location, pathsFile), paths); err != nil {
+		return errors.Wrap(err, "failed to merge stores")
+	}
+	if err := writeList(Fs.Join(location, archivedFile), archived); err != nil {
+		return errors.Wrap(err, "failed to merge stores")
+	}
+	return nil
+}
+
+// union returns the paths in a followed by those only in b.
+func union(a, b []string) []string {
+	paths := append([]string{}, a...)
+	for _, path := range b {
+		if !contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+func contains(paths []string, path string) bool {
+	for _, existing := range paths {
+		if existing == path {
 			return true
 		}
 	}
 	return false
 }
 
+// readPaths returns the paths of the dot-files in the store at
+// storePath, which is empty if the store does not exist yet.
+func readPaths(storePath string) ([]string, error) {
+	return readList(Fs.Join(storePath, pathsFile))
+}
+
+// readList returns the lines of the file at listPath, which is
+// empty if the file does not exist.
+func readList(listPath string) ([]string, error) {
+	listBytes, err := Afs.ReadFile(listPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	lines := strings.Split(string(listBytes), "\n")
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines, nil
+}
+
+func writeList(listPath string, lines []string) error {
+	var contents string
+	for _, line := range lines {
+		contents += line + "\n"
+	}
+	return Afs.WriteFile(listPath, []byte(contents), 0644)
+}
+
+// LoadFilesFromDisk loads every dot-file in the store at location
+// as it is, without reconciling the store with a config.
+func LoadFilesFromDisk(location string) ([]*file.DotFile, error) {
+	paths, err := readPaths(location)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load store")
+	}
+	var dotFiles []*file.DotFile
+	for _, path := range paths {
+		basePath := Fs.Join(location, storePath(path))
+		dotFile, err := file.LoadDotFileFromDisk(basePath, dotFilePath(path))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load store")
+		}
+		dotFiles = append(dotFiles, dotFile)
+	}
+	return dotFiles, nil
+}
+
+func entryWithPath(path string, entries []config.FileEntry) (config.FileEntry, bool) {
+	for _, entry := range entries {
+		if entry.Path == path {
+			return entry, true
+		}
+	}
+	return config.FileEntry{}, false
+}
+
+// isInactive reports whether path has entries in the config, but
+// none in withHistory and withoutHistory, the entries which apply to
+// this host.
+func isInactive(path string, config *config.Config, withHistory, withoutHistory []config.FileEntry) bool {
+	if _, ok := entryWithPath(path, withHistory); ok {
+		return false
+	}
+	if _, ok := entryWithPath(path, withoutHistory); ok {
+		return false
+	}
+	_, ok := entryWithPath(path, config.WithHistory)
+	if !ok {
+		_, ok = entryWithPath(path, config.WithoutHistory)
+	}
+	return ok
+}
+
 func storePath(path string) string {
 	sum := sha1.Sum([]byte(path))
 	return fmt.Sprintf("%x", sum)
@@ -122,11 +455,16 @@ func (store *Store) SaveToDisk() error {
 	if err != nil {
 		return errors.Wrap(err, "failed to save store to disk")
 	}
-	var pathFileContents string
+	var paths []string
 	for _, file := range store.files {
-		pathFileContents += file.RelativePath() + "\n"
+		paths = append(paths, file.RelativePath())
 	}
-	err = Afs.WriteFile(Fs.Join(store.path, "paths"), []byte(pathFileContents), 0644)
+	paths = append(paths, store.inactive...)
+	err = writeList(Fs.Join(store.path, pathsFile), paths)
+	if err != nil {
+		return errors.Wrap(err, "failed to save store to disk")
+	}
+	err = writeList(Fs.Join(store.path, archivedFile), store.archived)
 	if err != nil {
 		return errors.Wrap(err, "failed to save store to disk")
 	}
@@ -140,10 +478,36 @@ func (store *Store) SaveToDisk() error {
 		if err != nil {
 			return errors.Wrap(err, "failed to save store to disk")
 		}
+		if err = store.saveEntries(file.RelativePath()); err != nil {
+			return errors.Wrap(err, "failed to save store to disk")
+		}
+	}
+	for _, path := range store.inactive {
+		if err = store.saveEntries(path); err != nil {
+			return errors.Wrap(err, "failed to save store to disk")
+		}
 	}
 	return nil
 }
 
+// saveEntries saves the entries of the dot-file at path, if it has
+// any in the config.
+func (store *Store) saveEntries(path string) error {
+	entries, ok := store.entries[path]
+	if !ok {
+		return nil
+	}
+	fileDir := Fs.Join(store.path, storePath(path))
+	if exists, err := Afs.DirExists(fileDir); err != nil || !exists {
+		return err
+	}
+	entriesBytes, err := yaml.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	return Afs.WriteFile(Fs.Join(fileDir, entriesFile), entriesBytes, 0644)
+}
+
 func makeDirIfNotExist(dirPath string) error {
 	pathExists, err := Afs.Exists(dirPath)
 	if err != nil {
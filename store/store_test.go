@@ -40,6 +40,8 @@ func (suite *StoreSuite) SetupTest() {
 	paths := `.config/alacritty/alacritty.yml
 .tmux.conf`
 	Afs.WriteFile("store/paths", []byte(paths), 0644)
+	Afs.Remove("store/archived")
+	Afs.Remove("store/97aa776c8b768a52732c7978fd5f0af5ce5a1135/entries")
 	Afs.Mkdir("store/14b4f00abd93c6222516ff054e4a9f66295d03fa", 0755)
 	buf, err := os.ReadFile(filepath.Join("testdata", "alacritty.yml"))
 	if err != nil {
@@ -195,11 +197,106 @@ func (suite *StoreSuite) TestLoadStoreFileNotInConfig() {
 	suite.Len(store.files, 1)
 	suite.True(containsFilePath(store.files, ".config/alacritty/alacritty.yml"))
 
+	suite.Equal([]string{".tmux.conf"}, store.Archived())
+	suite.Equal([]string{".tmux.conf"}, store.NewlyArchived())
+	suite.Nil(store.SaveToDisk())
+
 	var exists bool
 	exists, _ = Afs.DirExists("store/14b4f00abd93c6222516ff054e4a9f66295d03fa")
 	suite.True(exists)
 	exists, _ = Afs.DirExists("store/97aa776c8b768a52732c7978fd5f0af5ce5a1135")
+	suite.True(exists)
+	paths, _ := readPaths("store")
+	suite.Equal([]string{".config/alacritty/alacritty.yml"}, paths)
+	archived, _ := readList("store/archived")
+	suite.Equal([]string{".tmux.conf"}, archived)
+
+	store, err = LoadFromDisk(config)
+	suite.Nil(err)
+	suite.Equal([]string{".tmux.conf"}, store.Archived())
+	suite.Len(store.NewlyArchived(), 0)
+	dotFile, err := store.LoadArchived(".tmux.conf")
+	suite.Nil(err)
+	suite.Equal("tmux", dotFile.Mnemonic())
+	suite.Len(store.files, 1)
+
+	suite.Nil(store.Purge(".tmux.conf"))
+	suite.Len(store.Archived(), 0)
+	exists, _ = Afs.DirExists("store/97aa776c8b768a52732c7978fd5f0af5ce5a1135")
 	suite.False(exists)
+	suite.NotNil(store.Purge(".tmux.conf"))
+}
+
+func (suite *StoreSuite) TestRestoreArchived() {
+	storeConfig := &config.Config{
+		Name: "Linux",
+		WithHistory: []config.FileEntry{
+			{
+				Path:     ".config/alacritty/alacritty.yml",
+				Mnemonic: "alacritty",
+			},
+		},
+		StoreLocation: "store",
+	}
+	store, err := LoadFromDisk(storeConfig)
+	suite.Nil(err)
+	suite.Nil(store.SaveToDisk())
+
+	dotFile, entries, err := store.Restore(".tmux.conf")
+	suite.Nil(err)
+	suite.Equal("tmux", dotFile.Mnemonic())
+	suite.Equal(Entries{}, entries)
+	suite.Len(store.files, 2)
+	suite.Len(store.Archived(), 0)
+	_, _, err = store.Restore(".tmux.conf")
+	suite.NotNil(err)
+
+	// Putting the entry back in the config restores it as well
+	store, err = LoadFromDisk(storeConfig)
+	suite.Nil(err)
+	suite.Equal([]string{".tmux.conf"}, store.Archived())
+	storeConfig.WithoutHistory = []config.FileEntry{{Path: ".tmux.conf", Mnemonic: "tmux"}}
+	store, err = LoadFromDisk(storeConfig)
+	suite.Nil(err)
+	suite.Len(store.files, 2)
+	suite.Len(store.Archived(), 0)
+}
+
+func (suite *StoreSuite) TestLoadStoreInactiveEntry() {
+	Host = func() config.Host {
+		return config.Host{Hostname: "desktop", OS: "linux", LookupEnv: func(string) (string, bool) { return "", false }}
+	}
+	defer func() { Host = config.CurrentHost }()
+	config := &config.Config{
+		Name: "Linux",
+		WithHistory: []config.FileEntry{
+			{
+				Path:     ".config/alacritty/alacritty.yml",
+				Mnemonic: "alacritty",
+				When:     &config.Condition{Hostname: config.StringList{"laptop"}},
+			},
+		},
+		WithoutHistory: []config.FileEntry{
+			{
+				Path:     ".tmux.conf",
+				Mnemonic: "tmux",
+				When:     &config.Condition{OS: config.StringList{"darwin", "linux"}},
+			},
+		},
+		StoreLocation: "store",
+	}
+
+	store, err := LoadFromDisk(config)
+	suite.Nil(err)
+	suite.Len(store.files, 1)
+	suite.True(containsFilePath(store.files, ".tmux.conf"))
+	suite.Nil(store.SaveToDisk())
+
+	exists, _ := Afs.DirExists("store/14b4f00abd93c6222516ff054e4a9f66295d03fa")
+	suite.True(exists)
+	paths, err := readPaths("store")
+	suite.Nil(err)
+	suite.ElementsMatch([]string{".tmux.conf", ".config/alacritty/alacritty.yml"}, paths)
 }
 
 func (suite *StoreSuite) TestSaveNewStore() {
@@ -226,3 +323,91 @@ func (suite *StoreSuite) TestSaveNewStore() {
 	err = store.SaveToDisk()
 	suite.Nil(err)
 }
+
+func (suite *StoreSuite) TestMergeManifests() {
+	Afs.WriteFile("store/archived", []byte(".vimrc\n.zshrc\n"), 0644)
+	Afs.MkdirAll("other", 0755)
+	Afs.WriteFile("other/paths", []byte(".tmux.conf\n.zshrc\n"), 0644)
+	Afs.WriteFile("other/archived", []byte(".bashrc\n"), 0644)
+
+	suite.Nil(MergeManifests("store", "other"))
+	paths, err := readPaths("store")
+	suite.Nil(err)
+	suite.Equal([]string{".config/alacritty/alacritty.yml", ".tmux.conf", ".zshrc"}, paths)
+	archived, err := readList("store/archived")
+	suite.Nil(err)
+	suite.Equal([]string{".vimrc", ".bashrc"}, archived)
+}
+
+func (suite *StoreSuite) TestLoadStoreEntryPerHost() {
+	Host = func() config.Host {
+		return config.Host{Hostname: "desktop", OS: "linux", LookupEnv: func(string) (string, bool) { return "", false }}
+	}
+	defer func() { Host = config.CurrentHost }()
+	config := &config.Config{
+		Name: "Linux",
+		WithHistory: []config.FileEntry{
+			{
+				Path:     ".tmux.conf",
+				Mnemonic: "tmux",
+				When:     &config.Condition{Hostname: config.StringList{"laptop"}},
+			},
+			{
+				Path:     ".config/alacritty/alacritty.yml",
+				Mnemonic: "alacritty",
+			},
+		},
+		WithoutHistory: []config.FileEntry{
+			{
+				Path:     ".tmux.conf",
+				Mnemonic: "tmux",
+				When:     &config.Condition{Hostname: config.StringList{"desktop"}},
+			},
+		},
+		StoreLocation: "store",
+	}
+
+	// The entry for this host is loaded, on every load
+	for i := 0; i < 2; i++ {
+		store, err := LoadFromDisk(config)
+		suite.Nil(err)
+		suite.Len(store.files, 2)
+		suite.True(containsFilePath(store.files, ".tmux.conf"))
+		suite.Len(store.inactive, 0)
+		suite.Nil(store.SaveToDisk())
+	}
+}
+
+func (suite *StoreSuite) TestRestoreArchivedEntries() {
+	tmuxEntry := config.FileEntry{
+		Path:     ".tmux.conf",
+		Mnemonic: "tmux",
+		Deploy:   config.SymlinkDeploy,
+		Format:   "yaml",
+		When:     &config.Condition{OS: config.StringList{"linux"}},
+		Hooks:    &config.Hooks{PostApply: config.StringList{"tmux source-file ~/.tmux.conf"}},
+	}
+	storeConfig := &config.Config{
+		Name: "Linux",
+		WithHistory: []config.FileEntry{
+			{
+				Path:     ".config/alacritty/alacritty.yml",
+				Mnemonic: "alacritty",
+			},
+		},
+		WithoutHistory: []config.FileEntry{tmuxEntry},
+		StoreLocation:  "store",
+	}
+	store, err := LoadFromDisk(storeConfig)
+	suite.Nil(err)
+	suite.Nil(store.SaveToDisk())
+
+	storeConfig.WithoutHistory = nil
+	store, err = LoadFromDisk(storeConfig)
+	suite.Nil(err)
+	suite.Equal([]string{".tmux.conf"}, store.Archived())
+	suite.Nil(store.SaveToDisk())
+	_, entries, err := store.Restore(".tmux.conf")
+	suite.Nil(err)
+	suite.Equal(Entries{WithoutHistory: []config.FileEntry{tmuxEntry}}, entries)
+}
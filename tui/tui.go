@@ -0,0 +1,306 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/RedDocMD/dotted/file"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Actions change files for the interface. They are given by the
+// command, so that checks and hooks run as with commit and apply.
+type Actions struct {
+	// Commit commits the working copy of dotFile, and describes what
+	// happened.
+	Commit func(dotFile *file.DotFile) (string, error)
+	// Checkout makes node the current commit of dotFile and writes it
+	// to disk, and describes what happened.
+	Checkout func(dotFile *file.DotFile, node *file.HistoryNode) (string, error)
+}
+
+// Run shows the interface for dotFiles full screen, until it is quit.
+func Run(dotFiles []*file.DotFile, actions Actions) error {
+	return tea.NewProgram(New(dotFiles, actions), tea.WithAltScreen()).Start()
+}
+
+type screen int
+
+const (
+	filesScreen screen = iota
+	historyScreen
+)
+
+// Model is the state of the interface, which lists files and shows
+// the history of one of them.
+type Model struct {
+	dotFiles  []*file.DotFile
+	modified  map[*file.DotFile]bool
+	actions   Actions
+	screen    screen
+	fileIndex int
+
+	// The history of the open file
+	commits     []commitLine
+	commitIndex int
+	// Shows the whole content of the commit rather than its diff
+	// against the parent
+	showContent   bool
+	preview       []string
+	previewOffset int
+
+	// Entering a tag for the selected commit
+	tagging  bool
+	tagInput []rune
+	// Set after warning that checking out the commit replaces
+	// uncommitted changes
+	confirmCheckout *file.HistoryNode
+
+	width, height int
+	status        string
+}
+
+// New makes the interface for dotFiles.
+func New(dotFiles []*file.DotFile, actions Actions) Model {
+	model := Model{
+		dotFiles: dotFiles,
+		modified: make(map[*file.DotFile]bool),
+		actions:  actions,
+		width:    80,
+		height:   24,
+	}
+	for _, dotFile := range dotFiles {
+		model.refreshModified(dotFile)
+	}
+	return model
+}
+
+func (model Model) Init() tea.Cmd {
+	return nil
+}
+
+func (model Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		model.width, model.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return model, tea.Quit
+		}
+		if model.tagging {
+			model.updateTag(msg)
+			return model, nil
+		}
+		if msg.Type == tea.KeyRunes && len(msg.Runes) > 1 {
+			// Keys typed quickly or pasted come together
+			var cmd tea.Cmd
+			for _, r := range msg.Runes {
+				updated, runeCmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+				model = updated.(Model)
+				if runeCmd != nil {
+					cmd = runeCmd
+				}
+			}
+			return model, cmd
+		}
+		key := msg.String()
+		if key != "o" && key != "enter" {
+			model.confirmCheckout = nil
+		}
+		if model.screen == filesScreen {
+			return model.updateFiles(key)
+		}
+		return model.updateHistory(key)
+	}
+	return model, nil
+}
+
+func (model Model) updateFiles(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "q", "esc":
+		return model, tea.Quit
+	case "up", "k":
+		model.fileIndex = max(model.fileIndex-1, 0)
+	case "down", "j":
+		model.fileIndex = min(model.fileIndex+1, len(model.dotFiles)-1)
+	case "home", "g":
+		model.fileIndex = 0
+	case "end", "G":
+		model.fileIndex = len(model.dotFiles) - 1
+	case "enter", "right", "l":
+		if len(model.dotFiles) == 0 {
+			break
+		}
+		dotFile := model.selectedFile()
+		if !dotFile.HasHistory() {
+			model.status = fmt.Sprintf("%s has no history", dotFile.Path())
+			break
+		}
+		model.screen = historyScreen
+		model.showContent = false
+		model.status = ""
+		model.loadHistory(dotFile.CurrentHistory())
+	case "c":
+		if len(model.dotFiles) != 0 {
+			model.commit()
+		}
+	}
+	return model, nil
+}
+
+func (model Model) updateHistory(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "q":
+		return model, tea.Quit
+	case "esc", "left", "h", "backspace":
+		model.screen = filesScreen
+		model.status = ""
+	case "up", "k":
+		model.selectCommit(model.commitIndex - 1)
+	case "down", "j":
+		model.selectCommit(model.commitIndex + 1)
+	case "home", "g":
+		model.selectCommit(0)
+	case "end", "G":
+		model.selectCommit(len(model.commits) - 1)
+	case "pgdown", "J", " ":
+		model.previewOffset = min(model.previewOffset+model.previewHeight()/2,
+			max(len(model.preview)-model.previewHeight(), 0))
+	case "pgup", "K":
+		model.previewOffset = max(model.previewOffset-model.previewHeight()/2, 0)
+	case "d", "tab":
+		model.showContent = !model.showContent
+		model.loadPreview()
+	case "enter", "o":
+		model.checkout()
+	case "t":
+		model.tagging = true
+		model.tagInput = nil
+	case "T":
+		dotFile, node := model.selectedFile(), model.selectedCommit()
+		for _, tag := range append([]string{}, node.Tags()...) {
+			dotFile.Untag(tag)
+		}
+		model.status = fmt.Sprintf("Removed the tags of %s", shortUUID(node))
+		model.loadHistory(node)
+	case "c":
+		model.commit()
+	}
+	return model, nil
+}
+
+// updateTag edits the tag being entered, and tags the selected commit
+// with it on enter.
+func (model *Model) updateTag(msg tea.KeyMsg) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		model.tagging = false
+	case tea.KeyEnter:
+		model.tagging = false
+		if len(model.tagInput) == 0 {
+			break
+		}
+		node := model.selectedCommit()
+		if err := model.selectedFile().Tag(node, string(model.tagInput)); err != nil {
+			model.status = err.Error()
+			break
+		}
+		model.status = fmt.Sprintf("Tagged %s as %s", shortUUID(node), string(model.tagInput))
+		model.loadHistory(node)
+	case tea.KeyBackspace:
+		if len(model.tagInput) != 0 {
+			model.tagInput = model.tagInput[:len(model.tagInput)-1]
+		}
+	case tea.KeyRunes:
+		model.tagInput = append(model.tagInput, msg.Runes...)
+	}
+}
+
+// commit commits the selected file, and shows the new commit if its
+// history is open.
+func (model *Model) commit() {
+	dotFile := model.selectedFile()
+	message, err := model.actions.Commit(dotFile)
+	if err != nil {
+		model.status = err.Error()
+		return
+	}
+	model.status = message
+	model.refreshModified(dotFile)
+	if model.screen == historyScreen {
+		model.loadHistory(dotFile.CurrentHistory())
+	}
+}
+
+// checkout checks out the selected commit, after a warning if the
+// file has uncommitted changes.
+func (model *Model) checkout() {
+	dotFile, node := model.selectedFile(), model.selectedCommit()
+	if node == dotFile.CurrentHistory() && !model.modified[dotFile] {
+		model.status = fmt.Sprintf("%s is already checked out", shortUUID(node))
+		return
+	}
+	if model.modified[dotFile] && model.confirmCheckout != node {
+		model.confirmCheckout = node
+		model.status = fmt.Sprintf("%s has uncommitted changes, which are backed up, "+
+			"press o again to check out %s", dotFile.Path(), shortUUID(node))
+		return
+	}
+	model.confirmCheckout = nil
+	message, err := model.actions.Checkout(dotFile, node)
+	if err != nil {
+		model.status = err.Error()
+	} else {
+		model.status = message
+	}
+	model.refreshModified(dotFile)
+	model.loadHistory(node)
+}
+
+func (model *Model) refreshModified(dotFile *file.DotFile) {
+	modified, err := dotFile.IsModified()
+	model.modified[dotFile] = err == nil && modified
+}
+
+func (model Model) selectedFile() *file.DotFile {
+	return model.dotFiles[model.fileIndex]
+}
+
+func (model Model) selectedCommit() *file.HistoryNode {
+	return model.commits[model.commitIndex].node
+}
+
+// loadHistory lists the history of the selected file and selects
+// node.
+func (model *Model) loadHistory(node *file.HistoryNode) {
+	dotFile := model.selectedFile()
+	model.commits = commitLines(dotFile.HistoryRoot(), dotFile.CurrentHistory())
+	model.commitIndex = 0
+	for i, commit := range model.commits {
+		if commit.node == node {
+			model.commitIndex = i
+		}
+	}
+	model.loadPreview()
+}
+
+func (model *Model) selectCommit(index int) {
+	index = max(min(index, len(model.commits)-1), 0)
+	if index != model.commitIndex {
+		model.commitIndex = index
+		model.loadPreview()
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
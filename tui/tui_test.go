@@ -0,0 +1,170 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/RedDocMD/dotted/file"
+	"github.com/RedDocMD/dotted/fs"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/suite"
+)
+
+type TuiTestSuite struct {
+	suite.Suite
+	vimrc     *file.DotFile
+	tmux      *file.DotFile
+	first     *file.HistoryNode
+	second    *file.HistoryNode
+	checkouts []*file.HistoryNode
+}
+
+func (suite *TuiTestSuite) SetupSuite() {
+	file.Fs = fs.MockFs
+	file.Afs = fs.MockAfs
+}
+
+func (suite *TuiTestSuite) SetupTest() {
+	file.Afs.WriteFile("/home/dknite/.vimrc", []byte("set number\n"), 0644)
+	file.Afs.WriteFile("/home/dknite/.tmux.conf", []byte("set -g mouse on\n"), 0644)
+	suite.vimrc, _ = file.NewDotFile("/home/dknite/.vimrc", "vim", true)
+	suite.tmux, _ = file.NewDotFile("/home/dknite/.tmux.conf", "tmux", false)
+	suite.first = suite.vimrc.CurrentHistory()
+	file.Afs.WriteFile("/home/dknite/.vimrc", []byte("set number\nsyntax on\n"), 0644)
+	suite.vimrc.AddCommit()
+	suite.second = suite.vimrc.CurrentHistory()
+	suite.checkouts = nil
+}
+
+func (suite *TuiTestSuite) TearDownTest() {
+	file.Fs.RemoveAll("/")
+}
+
+func (suite *TuiTestSuite) TearDownSuite() {
+	file.Fs = fs.OsFs
+	file.Afs = fs.OsAfs
+}
+
+func TestTuiTestSuite(t *testing.T) {
+	suite.Run(t, new(TuiTestSuite))
+}
+
+func (suite *TuiTestSuite) model() Model {
+	return New([]*file.DotFile{suite.vimrc, suite.tmux}, Actions{
+		Commit: func(dotFile *file.DotFile) (string, error) {
+			if _, err := dotFile.AddCommit(); err != nil {
+				return "", err
+			}
+			return "Committed " + dotFile.Path(), nil
+		},
+		Checkout: func(dotFile *file.DotFile, node *file.HistoryNode) (string, error) {
+			suite.checkouts = append(suite.checkouts, node)
+			file.Afs.WriteFile(dotFile.Path(), []byte(node.Content()), 0644)
+			return "Checked out", dotFile.Checkout(node)
+		},
+	})
+}
+
+func press(model Model, keys ...string) Model {
+	for _, key := range keys {
+		var msg tea.KeyMsg
+		switch key {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "up":
+			msg = tea.KeyMsg{Type: tea.KeyUp}
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		}
+		updated, _ := model.Update(msg)
+		model = updated.(Model)
+	}
+	return model
+}
+
+func (suite *TuiTestSuite) TestFiles() {
+	model := suite.model()
+	view := model.View()
+	suite.Contains(view, "dtd ui: 2 files")
+	suite.Contains(view, "  /home/dknite/.vimrc (vim)")
+	suite.Contains(view, "  /home/dknite/.tmux.conf (tmux) without history")
+	suite.Contains(view, filesHelp)
+	suite.Len(strings.Split(view, "\n"), 24)
+
+	model = press(model, "down", "enter")
+	suite.Equal(filesScreen, model.screen)
+	suite.Equal("/home/dknite/.tmux.conf has no history", model.status)
+
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	suite.NotNil(cmd)
+}
+
+func (suite *TuiTestSuite) TestHistory() {
+	model := press(suite.model(), "enter")
+	suite.Equal(historyScreen, model.screen)
+	suite.Len(model.commits, 2)
+	suite.Equal(suite.second, model.selectedCommit())
+	view := model.View()
+	suite.Contains(view, fmt.Sprintf("└──%s", shortUUID(suite.second)))
+	suite.Contains(view, "(current)")
+	suite.Contains(view, "diff against its parent")
+	suite.Contains(view, "@@ -1,1 +1,2 @@")
+	suite.Contains(view, "+syntax on")
+
+	model = press(model, "d")
+	suite.Contains(model.View(), "content")
+	suite.Equal([]string{"set number", "syntax on"}, model.preview)
+
+	model = press(model, "up")
+	suite.Equal(suite.first, model.selectedCommit())
+	suite.Equal([]string{"set number"}, model.preview)
+
+	model = press(model, "esc")
+	suite.Equal(filesScreen, model.screen)
+}
+
+func (suite *TuiTestSuite) TestCheckout() {
+	model := press(suite.model(), "enter", "up", "o")
+	suite.Equal([]*file.HistoryNode{suite.first}, suite.checkouts)
+	suite.Equal(suite.first, suite.vimrc.CurrentHistory())
+	suite.Equal("Checked out", model.status)
+
+	// Uncommitted changes need the checkout to be confirmed
+	file.Afs.WriteFile("/home/dknite/.vimrc", []byte("set nonumber\n"), 0644)
+	model.refreshModified(suite.vimrc)
+	model = press(model, "down", "o")
+	suite.Len(suite.checkouts, 1)
+	suite.Contains(model.status, "uncommitted changes")
+	model = press(model, "o")
+	suite.Equal([]*file.HistoryNode{suite.first, suite.second}, suite.checkouts)
+	suite.False(model.modified[suite.vimrc])
+
+	model = press(model, "o")
+	suite.Len(suite.checkouts, 2)
+	suite.Contains(model.status, "is already checked out")
+}
+
+func (suite *TuiTestSuite) TestTagAndCommit() {
+	model := press(suite.model(), "enter", "tst", "able")
+	suite.Contains(model.View(), "stable█")
+	model = press(model, "enter")
+	suite.Equal([]string{"stable"}, suite.second.Tags())
+	suite.Contains(model.View(), "[stable]")
+
+	model = press(model, "T")
+	suite.Len(suite.second.Tags(), 0)
+
+	file.Afs.WriteFile("/home/dknite/.vimrc", []byte("set number\nsyntax off\n"), 0644)
+	model.refreshModified(suite.vimrc)
+	suite.Contains(model.View(), "(modified)")
+	model = press(model, "c")
+	suite.Equal("Committed /home/dknite/.vimrc", model.status)
+	suite.Len(model.commits, 3)
+	suite.Equal(suite.vimrc.CurrentHistory(), model.selectedCommit())
+	suite.NotContains(model.View(), "(modified)")
+}
@@ -0,0 +1,250 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/RedDocMD/dotted/diff"
+	"github.com/RedDocMD/dotted/file"
+	"github.com/fatih/color"
+)
+
+var (
+	bold     = color.New(color.Bold).SprintFunc()
+	faint    = color.New(color.Faint).SprintFunc()
+	green    = color.New(color.FgGreen).SprintFunc()
+	red      = color.New(color.FgRed).SprintFunc()
+	cyan     = color.New(color.FgCyan).SprintFunc()
+	yellow   = color.New(color.FgYellow).SprintFunc()
+	reversed = color.New(color.ReverseVideo).SprintFunc()
+	plain    = fmt.Sprint
+)
+
+const filesHelp = "↑/↓ move  enter history  c commit  q quit"
+const historyHelp = "↑/↓ move  o check out  t tag  T untag  c commit  d diff/content  pgup/pgdn scroll  esc back"
+
+// commitLine is a commit in the tree of a history.
+type commitLine struct {
+	node    *file.HistoryNode
+	prefix  string // Branches of the tree
+	current bool
+}
+
+// commitLines lists the history rooted at root depth-first, drawn as
+// a tree like history --list.
+func commitLines(root, current *file.HistoryNode) []commitLine {
+	var lines []commitLine
+	var add func(node *file.HistoryNode, indent, branch string)
+	add = func(node *file.HistoryNode, indent, branch string) {
+		lines = append(lines, commitLine{node, indent + branch, node == current})
+		children := node.Children()
+		if len(branch) != 0 {
+			if branch == "└──" {
+				indent += "   "
+			} else {
+				indent += "│  "
+			}
+		}
+		for i, child := range children {
+			if i == len(children)-1 {
+				add(child, indent, "└──")
+			} else {
+				add(child, indent, "├──")
+			}
+		}
+	}
+	add(root, "", "")
+	return lines
+}
+
+func shortUUID(node *file.HistoryNode) string {
+	return node.UUID()[:8]
+}
+
+// styledLine is a line of the screen, coloured once it is cut to
+// the width of the screen.
+type styledLine struct {
+	text  string
+	paint func(...interface{}) string
+}
+
+// loadPreview shows the selected commit as a diff against its parent,
+// or as its whole content.
+func (model *Model) loadPreview() {
+	model.previewOffset = 0
+	model.preview = nil
+	node := model.selectedCommit()
+	parent := node.Parent()
+	if model.showContent || parent == nil {
+		for _, line := range strings.Split(strings.TrimSuffix(node.Content(), "\n"), "\n") {
+			model.preview = append(model.preview, line)
+		}
+		return
+	}
+	hunks := diff.Hunks(diff.Lines(parent.Content(), node.Content()), 3)
+	if len(hunks) == 0 {
+		model.preview = []string{"no changes"}
+	}
+	for _, hunk := range hunks {
+		model.preview = append(model.preview, hunk.Header())
+		for _, line := range hunk.Lines {
+			model.preview = append(model.preview, line.String())
+		}
+	}
+}
+
+// previewStyle colours the line of a diff, and nothing else.
+func (model Model) previewStyle(line string) func(...interface{}) string {
+	if model.showContent || model.selectedCommit().Parent() == nil {
+		return plain
+	}
+	switch {
+	case strings.HasPrefix(line, "@@"):
+		return cyan
+	case strings.HasPrefix(line, "+"):
+		return green
+	case strings.HasPrefix(line, "-"):
+		return red
+	}
+	return plain
+}
+
+// treeHeight is the number of lines showing the history, which takes
+// up to a third of the screen.
+func (model Model) treeHeight() int {
+	return max(min(len(model.commits), (model.height-3)/3), 1)
+}
+
+func (model Model) previewHeight() int {
+	return max(model.height-3-model.treeHeight(), 1)
+}
+
+func (model Model) View() string {
+	var lines []styledLine
+	if model.screen == filesScreen {
+		lines = model.filesView()
+	} else {
+		lines = model.historyView()
+	}
+	lines = append(lines, model.statusLine())
+	var view strings.Builder
+	for i, line := range lines {
+		if i != 0 {
+			view.WriteString("\n")
+		}
+		view.WriteString(line.paint(fit(line.text, model.width)))
+	}
+	return view.String()
+}
+
+func (model Model) filesView() []styledLine {
+	lines := []styledLine{{fmt.Sprintf("dtd ui: %d files", len(model.dotFiles)), bold}}
+	height := max(model.height-2, 1)
+	start := window(model.fileIndex, len(model.dotFiles), height)
+	for i := start; i < len(model.dotFiles) && i < start+height; i++ {
+		dotFile := model.dotFiles[i]
+		text := "  "
+		if model.modified[dotFile] {
+			text = "M "
+		}
+		text += dotFile.Path()
+		if len(dotFile.Mnemonic()) != 0 {
+			text += fmt.Sprintf(" (%s)", dotFile.Mnemonic())
+		}
+		if !dotFile.HasHistory() {
+			text += " without history"
+		}
+		paint := plain
+		if i == model.fileIndex {
+			paint = reversed
+		} else if !dotFile.HasHistory() {
+			paint = faint
+		}
+		lines = append(lines, styledLine{text, paint})
+	}
+	for len(lines) < height+1 {
+		lines = append(lines, styledLine{"", plain})
+	}
+	return lines
+}
+
+func (model Model) historyView() []styledLine {
+	dotFile := model.selectedFile()
+	title := "dtd ui: " + dotFile.Path()
+	if model.modified[dotFile] {
+		title += " (modified)"
+	}
+	lines := []styledLine{{title, bold}}
+
+	height := model.treeHeight()
+	start := window(model.commitIndex, len(model.commits), height)
+	for i := start; i < len(model.commits) && i < start+height; i++ {
+		commit := model.commits[i]
+		node := commit.node
+		text := fmt.Sprintf("%s%s %s", commit.prefix, shortUUID(node), node.Timestamp().Format("2006-01-02 15:04:05"))
+		if len(node.Tags()) != 0 {
+			text += fmt.Sprintf(" [%s]", strings.Join(node.Tags(), ", "))
+		}
+		if commit.current {
+			text += " (current)"
+		}
+		if len(node.Message()) != 0 {
+			text += " " + strings.SplitN(node.Message(), "\n", 2)[0]
+		}
+		paint := plain
+		if i == model.commitIndex {
+			paint = reversed
+		} else if commit.current {
+			paint = green
+		}
+		lines = append(lines, styledLine{text, paint})
+	}
+
+	node := model.selectedCommit()
+	separator := fmt.Sprintf("── %s: diff against its parent ", shortUUID(node))
+	if model.showContent || node.Parent() == nil {
+		separator = fmt.Sprintf("── %s: content ", shortUUID(node))
+	}
+	separator += strings.Repeat("─", max(model.width-len([]rune(separator)), 0))
+	lines = append(lines, styledLine{separator, faint})
+
+	height = model.previewHeight()
+	for i := model.previewOffset; i < len(model.preview) && i < model.previewOffset+height; i++ {
+		line := model.preview[i]
+		lines = append(lines, styledLine{line, model.previewStyle(line)})
+	}
+	for len(lines) < model.height-1 {
+		lines = append(lines, styledLine{"", plain})
+	}
+	return lines
+}
+
+func (model Model) statusLine() styledLine {
+	switch {
+	case model.tagging:
+		return styledLine{fmt.Sprintf("Tag %s as: %s█", shortUUID(model.selectedCommit()), string(model.tagInput)), plain}
+	case len(model.status) != 0:
+		return styledLine{model.status, yellow}
+	case model.screen == filesScreen:
+		return styledLine{filesHelp, faint}
+	default:
+		return styledLine{historyHelp, faint}
+	}
+}
+
+// window is the first of count lines to show in height lines, so
+// that selected is visible.
+func window(selected, count, height int) int {
+	start := selected - height/2
+	return max(min(start, count-height), 0)
+}
+
+// fit cuts line to width columns, expanding tabs.
+func fit(line string, width int) string {
+	line = strings.ReplaceAll(line, "\t", "    ")
+	runes := []rune(line)
+	if width > 0 && len(runes) > width {
+		return string(runes[:width])
+	}
+	return line
+}
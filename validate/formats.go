@@ -0,0 +1,149 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+
+	"github.com/pelletier/go-toml"
+	"gopkg.in/yaml.v3"
+)
+
+var yamlErrorPattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// decodeYAML decodes every document in content, returning a list of
+// them if there is more than one. The YAML parser only reports a
+// line, which is often that of the block around the error.
+func decodeYAML(content []byte) (interface{}, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	var documents []interface{}
+	for {
+		var document interface{}
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			break
+		}
+		if typeErr, ok := err.(*yaml.TypeError); ok {
+			// Such as duplicate keys, which are broken for most programs
+			err = errors.New(typeErr.Errors[0])
+		}
+		if err != nil {
+			syntaxErr := &SyntaxError{Format: "yaml", Message: err.Error()}
+			if match := yamlErrorPattern.FindStringSubmatch(err.Error()); match != nil {
+				syntaxErr.Line, _ = strconv.Atoi(match[1])
+				syntaxErr.Message = match[2]
+			}
+			return nil, syntaxErr
+		}
+		documents = append(documents, document)
+	}
+	switch len(documents) {
+	case 0:
+		return nil, nil
+	case 1:
+		return documents[0], nil
+	default:
+		return documents, nil
+	}
+}
+
+func decodeJSON(content []byte) (interface{}, error) {
+	return parseJSON("json", content, content)
+}
+
+// decodeJSONC decodes JSON with comments and trailing commas, as in
+// the settings of VS Code.
+func decodeJSONC(content []byte) (interface{}, error) {
+	return parseJSON("jsonc", content, stripJSONC(content))
+}
+
+// parseJSON decodes stripped, which has the same layout as content,
+// and reports errors at their position in content.
+func parseJSON(format string, content, stripped []byte) (interface{}, error) {
+	var value interface{}
+	err := json.Unmarshal(stripped, &value)
+	if err == nil {
+		return value, nil
+	}
+	syntaxErr := &SyntaxError{Format: format, Message: err.Error()}
+	if jsonErr, ok := err.(*json.SyntaxError); ok {
+		// The offset is just past the byte which broke the syntax
+		offset := int(jsonErr.Offset)
+		if offset > 0 {
+			offset -= 1
+		}
+		syntaxErr.Line, syntaxErr.Column = position(content, offset)
+	}
+	return nil, syntaxErr
+}
+
+// stripJSONC blanks out comments and trailing commas with spaces,
+// keeping newlines so positions do not move.
+func stripJSONC(content []byte) []byte {
+	stripped := make([]byte, len(content))
+	copy(stripped, content)
+	blank := func(from, to int) {
+		for i := from; i < to; i++ {
+			if stripped[i] != '\n' {
+				stripped[i] = ' '
+			}
+		}
+	}
+	lastComma := -1
+	for i := 0; i < len(stripped); i++ {
+		switch c := stripped[i]; {
+		case c == '"':
+			lastComma = -1
+			for i++; i < len(stripped) && stripped[i] != '"'; i++ {
+				if stripped[i] == '\\' {
+					i++
+				}
+			}
+		case c == '/' && i+1 < len(stripped) && stripped[i+1] == '/':
+			end := bytes.IndexByte(stripped[i:], '\n')
+			if end == -1 {
+				end = len(stripped) - i
+			}
+			blank(i, i+end)
+			i += end - 1
+		case c == '/' && i+1 < len(stripped) && stripped[i+1] == '*':
+			end := bytes.Index(stripped[i+2:], []byte("*/"))
+			if end == -1 {
+				// Left for the parser to report
+				continue
+			}
+			blank(i, i+end+4)
+			i += end + 3
+		case c == ',':
+			lastComma = i
+		case c == '}' || c == ']':
+			if lastComma != -1 {
+				blank(lastComma, lastComma+1)
+			}
+			lastComma = -1
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		default:
+			lastComma = -1
+		}
+	}
+	return stripped
+}
+
+var tomlErrorPattern = regexp.MustCompile(`^\((\d+), (\d+)\): (.*)$`)
+
+func decodeTOML(content []byte) (interface{}, error) {
+	tree, err := toml.LoadBytes(content)
+	if err == nil {
+		return tree.ToMap(), nil
+	}
+	syntaxErr := &SyntaxError{Format: "toml", Message: err.Error()}
+	if match := tomlErrorPattern.FindStringSubmatch(err.Error()); match != nil {
+		syntaxErr.Line, _ = strconv.Atoi(match[1])
+		syntaxErr.Column, _ = strconv.Atoi(match[2])
+		syntaxErr.Message = match[3]
+	}
+	return nil, syntaxErr
+}
@@ -0,0 +1,119 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Decoder parses content into maps, lists and scalars, returning a
+// *SyntaxError if it is broken.
+type Decoder func(content []byte) (interface{}, error)
+
+// None is the format of an entry which should not be validated, even
+// if its extension has a validator.
+const None = "none"
+
+var decoders = map[string]Decoder{}
+var extensions = map[string]string{}
+
+// Register adds a decoder for format, which is picked for files with
+// any of the given extensions, such as .yml.
+func Register(format string, decoder Decoder, exts ...string) {
+	decoders[format] = decoder
+	for _, ext := range exts {
+		extensions[ext] = format
+	}
+}
+
+func init() {
+	Register("yaml", decodeYAML, ".yml", ".yaml")
+	// Files such as .vscode/settings.json are JSON with comments, so
+	// strict JSON is only checked with format: json
+	Register("json", decodeJSON)
+	Register("jsonc", decodeJSONC, ".json", ".jsonc")
+	Register("toml", decodeTOML, ".toml")
+}
+
+// IsKnown reports whether format can be given in the config.
+func IsKnown(format string) bool {
+	_, ok := decoders[format]
+	return ok || format == None
+}
+
+// Formats lists the formats with a decoder.
+func Formats() []string {
+	var formats []string
+	for format := range decoders {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// FormatOf returns the format of the file at path, which is format
+// if it is set and otherwise found by the extension of path. It is
+// empty if the file has no known format.
+func FormatOf(path, format string) string {
+	if len(format) != 0 {
+		return format
+	}
+	return extensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// Validate checks content, which is of the file at path, with the
+// decoder of its format. Files with no known format always pass.
+func Validate(path, format string, content []byte) error {
+	_, err := Decode(path, format, content)
+	if err == ErrNoFormat {
+		return nil
+	}
+	return err
+}
+
+// ErrNoFormat is returned by Decode for files with no known format.
+var ErrNoFormat = errors.New("no known format")
+
+// Decode parses content, which is of the file at path, with the
+// decoder of its format.
+func Decode(path, format string, content []byte) (interface{}, error) {
+	decoder, ok := decoders[FormatOf(path, format)]
+	if !ok {
+		return nil, ErrNoFormat
+	}
+	return decoder(content)
+}
+
+// SyntaxError is broken syntax in a file. Line and Column start at 1,
+// and are 0 if the parser does not say. Without a column, the line is
+// only roughly where the error is.
+type SyntaxError struct {
+	Format  string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	switch {
+	case e.Line == 0:
+		return fmt.Sprintf("invalid %s: %s", e.Format, e.Message)
+	case e.Column == 0:
+		return fmt.Sprintf("invalid %s near line %d: %s", e.Format, e.Line, e.Message)
+	default:
+		return fmt.Sprintf("invalid %s at line %d, column %d: %s", e.Format, e.Line, e.Column, e.Message)
+	}
+}
+
+// position finds the line and column of the byte at offset.
+func position(content []byte, offset int) (int, int) {
+	if offset > len(content) {
+		offset = len(content)
+	}
+	before := content[:offset]
+	line := 1 + strings.Count(string(before), "\n")
+	column := offset - strings.LastIndex(string(before), "\n")
+	return line, column
+}
@@ -0,0 +1,72 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatOf(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("yaml", FormatOf("/home/dknite/.config/alacritty/alacritty.yml", ""))
+	assert.Equal("yaml", FormatOf("/home/dknite/.dotted/dotted.YAML", ""))
+	assert.Equal("jsonc", FormatOf("/home/dknite/.vscode/settings.json", ""))
+	assert.Equal("json", FormatOf("/home/dknite/.vscode/settings.json", "json"))
+	assert.Equal("toml", FormatOf("/home/dknite/.config/starship.toml", ""))
+	assert.Equal("", FormatOf("/home/dknite/.tmux.conf", ""))
+	assert.Equal("toml", FormatOf("/home/dknite/.config/helix/config", "toml"))
+	assert.True(IsKnown("jsonc"))
+	assert.True(IsKnown(None))
+	assert.False(IsKnown("xml"))
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		path, format, content string
+		err                   string
+	}{
+		{"a.yml", "", "font:\n  size: 11\n", ""},
+		{"a.yml", "", "---\na: 1\n---\nb: 2\n", ""},
+		{"a.yml", "", "font:\n  size: 11\n bold: true\n", "invalid yaml near line 2: did not find expected key"},
+		{"a.yml", "", "a: 1\n---\nb: [2\n", "invalid yaml near line 2: did not find expected ',' or ']'"},
+		{"a.yml", "", "a: 1\n\tb: 2\n", "invalid yaml near line 2: found a tab character that violates indentation"},
+		{"a.yml", "", "a: 1\nb: 2\na: 3\n", "invalid yaml near line 3: mapping key \"a\" already defined at line 1"},
+		{"a.json", "", `{"a": [1, 2]}`, ""},
+		{"a.json", "json", "{\n  \"a\": 1\n  \"b\": 2\n}", "invalid json at line 3, column 3: invalid character '\"' after object key:value pair"},
+		{"a.json", "json", "{\"a\": 1,\n}", "invalid json at line 2, column 1: invalid character '}' looking for beginning of object key string"},
+		{"a.json", "json", "{\"a\": ", "invalid json at line 1, column 6: unexpected end of JSON input"},
+		{"a.json", "json", "{\n  // comment\n  \"a\": 1\n}", "invalid json at line 2, column 3: invalid character '/' looking for beginning of object key string"},
+		{"a.json", "", "{\n  // comment, with a comma\n  \"a\": \"b // c\", /* d */\n  \"e\": [1, 2,],\n}", ""},
+		{"a.json", "", "{\n  // comment\n  \"a\": 1 2\n}", "invalid jsonc at line 3, column 10: invalid character '2' after object key:value pair"},
+		{"settings.json", "", "{\n  // Appearance\n  \"editor.fontSize\": 14,\n  /* \"editor.minimap.enabled\": false, */\n  \"files.exclude\": {\"**/.git\": true},\n}\n", ""},
+		{"a.toml", "", "[font]\nsize = 11\n", ""},
+		{"a.toml", "", "[font]\nsize = = 11\n", "invalid toml at line 2, column 8: "},
+		{"a.json", None, "not json", ""},
+		{".tmux.conf", "", "{{{", ""},
+	}
+	for _, test := range tests {
+		err := Validate(test.path, test.format, []byte(test.content))
+		if len(test.err) == 0 {
+			assert.Nil(t, err, test.content)
+			continue
+		}
+		if assert.IsType(t, &SyntaxError{}, err, test.content) {
+			assert.Contains(t, err.Error(), test.err, test.content)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	assert := assert.New(t)
+	value, err := Decode("a.yml", "", []byte("font:\n  size: 11\n"))
+	assert.Nil(err)
+	assert.Equal(map[string]interface{}{"font": map[string]interface{}{"size": 11}}, value)
+	value, err = Decode("a.yml", "", []byte("a: 1\n---\nb: 2\n"))
+	assert.Nil(err)
+	assert.Equal([]interface{}{map[string]interface{}{"a": 1}, map[string]interface{}{"b": 2}}, value)
+	value, err = Decode("a.toml", "", []byte("[font]\nsize = 11\n"))
+	assert.Nil(err)
+	assert.Equal(map[string]interface{}{"font": map[string]interface{}{"size": int64(11)}}, value)
+	_, err = Decode(".tmux.conf", "", []byte("set -g mouse on"))
+	assert.Equal(ErrNoFormat, err)
+}
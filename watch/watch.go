@@ -0,0 +1,137 @@
+package watch
+
+import (
+	"os"
+	"time"
+
+	"github.com/RedDocMD/dotted/file"
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+)
+
+// DefaultQuietPeriod is how long a file must go without changes
+// before it is committed, so that the bursts of writes editors make
+// while saving end up as one commit.
+const DefaultQuietPeriod = 2 * time.Second
+
+// Watcher watches dot-files and reports each one which changed once
+// it has been quiet for a while.
+type Watcher struct {
+	watcher *fsnotify.Watcher
+	files   map[string]*file.DotFile // By watched path
+	quiet   time.Duration
+	pending map[string]time.Time // Time of the last event by watched path
+	// Watched paths which are gone, such as after a save renamed a
+	// new file over them, and need to be watched again
+	lost map[string]struct{}
+}
+
+// New creates a watcher for dotFiles. Each file is watched at its
+// work path, which is where commits read it from.
+func New(dotFiles []*file.DotFile, quiet time.Duration) (*Watcher, error) {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start watching")
+	}
+	watcher := &Watcher{
+		watcher: fsWatcher,
+		files:   make(map[string]*file.DotFile),
+		quiet:   quiet,
+		pending: make(map[string]time.Time),
+		lost:    make(map[string]struct{}),
+	}
+	for _, dotFile := range dotFiles {
+		path := dotFile.WorkPath()
+		watcher.files[path] = dotFile
+		if err = fsWatcher.Add(path); err != nil {
+			// Watched once it appears
+			watcher.lost[path] = struct{}{}
+		}
+	}
+	return watcher, nil
+}
+
+// Lost returns the paths which cannot be watched at the moment.
+func (watcher *Watcher) Lost() []string {
+	var lost []string
+	for path := range watcher.lost {
+		lost = append(lost, path)
+	}
+	return lost
+}
+
+// Run calls changed with every dot-file which changed and then was
+// quiet for the quiet period, until stop is closed or changed
+// returns an error. Errors from watching are passed to failed.
+func (watcher *Watcher) Run(stop <-chan struct{}, changed func(*file.DotFile) error, failed func(error)) error {
+	defer watcher.watcher.Close()
+	tick := watcher.quiet / 4
+	if tick <= 0 {
+		tick = time.Millisecond
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case event, ok := <-watcher.watcher.Events:
+			if !ok {
+				return nil
+			}
+			watcher.handle(event)
+		case err, ok := <-watcher.watcher.Errors:
+			if !ok {
+				return nil
+			}
+			failed(err)
+		case now := <-ticker.C:
+			watcher.rewatch()
+			for path, last := range watcher.pending {
+				if now.Sub(last) < watcher.quiet {
+					continue
+				}
+				delete(watcher.pending, path)
+				if _, ok := watcher.lost[path]; ok {
+					// Deleted rather than saved, nothing to commit
+					continue
+				}
+				if err := changed(watcher.files[path]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func (watcher *Watcher) handle(event fsnotify.Event) {
+	if _, ok := watcher.files[event.Name]; !ok {
+		return
+	}
+	if event.Op&fsnotify.Chmod == event.Op {
+		return
+	}
+	watcher.pending[event.Name] = time.Now()
+	if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+		// The watch went with the old file. Saving by renaming a new
+		// file over the old one leaves a file to watch again shortly.
+		watcher.watcher.Remove(event.Name)
+		watcher.lost[event.Name] = struct{}{}
+		watcher.rewatch()
+	}
+}
+
+// rewatch watches the lost paths which exist again, which counts as
+// a change to them.
+func (watcher *Watcher) rewatch() {
+	for path := range watcher.lost {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if err := watcher.watcher.Add(path); err != nil {
+			continue
+		}
+		delete(watcher.lost, path)
+		watcher.pending[path] = time.Now()
+	}
+}
@@ -0,0 +1,79 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/RedDocMD/dotted/file"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatch(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".tmux.conf")
+	if err := os.WriteFile(path, []byte("set -g mouse on\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dotFile, err := file.NewDotFile(path, "tmux", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quiet := 100 * time.Millisecond
+	watcher, err := New([]*file.DotFile{dotFile}, quiet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := make(chan *file.DotFile, 10)
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- watcher.Run(stop, func(changed *file.DotFile) error {
+			changes <- changed
+			return nil
+		}, func(err error) { t.Error(err) })
+	}()
+	expectChanges := func(count int) {
+		for i := 0; i < count; i++ {
+			select {
+			case changed := <-changes:
+				assert.Equal(dotFile, changed)
+			case <-time.After(10 * quiet):
+				t.Fatalf("expected %d changes, got %d", count, i)
+			}
+		}
+		select {
+		case <-changes:
+			t.Fatalf("expected %d changes, got more", count)
+		case <-time.After(3 * quiet):
+		}
+	}
+
+	// A burst of writes is one change
+	for i := 0; i < 5; i++ {
+		os.WriteFile(path, []byte("set -g mouse off\n"), 0644)
+		time.Sleep(quiet / 10)
+	}
+	expectChanges(1)
+
+	// Saving by renaming a new file over the old one
+	tmpPath := path + ".swp"
+	os.WriteFile(tmpPath, []byte("set -g base-index 1\n"), 0644)
+	os.Rename(tmpPath, path)
+	expectChanges(1)
+	// The file is still watched after the rename
+	os.WriteFile(path, []byte("set -g base-index 0\n"), 0644)
+	expectChanges(1)
+
+	// Deleting is not a change
+	os.Remove(path)
+	expectChanges(0)
+	os.WriteFile(path, []byte("set -g mouse on\n"), 0644)
+	expectChanges(1)
+
+	close(stop)
+	assert.Nil(<-done)
+}